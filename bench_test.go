@@ -0,0 +1,71 @@
+package cmdline
+
+import "testing"
+
+// benchCmdlines are command lines of a typical linux host
+var benchCmdlines = []string{
+	"/sbin/init splash",
+	"/usr/sbin/sshd -D",
+	"nginx: worker process",
+	"/usr/sbin/nginx -g daemon on; master_process on;",
+	"/usr/lib/postgresql/14/bin/postgres -D /var/lib/postgresql/14/main -c config_file=/etc/postgresql/14/main/postgresql.conf",
+	"/usr/bin/python3 /usr/local/bin/gunicorn --workers 4 --bind 0.0.0.0:8000 app.wsgi:application",
+	"python3 -m http.server 8000",
+	"ruby -I lib bin/rails server -p 3000",
+	"/usr/lib/jvm/java-17-openjdk/bin/java -Xms1g -Xmx1g -Dcom.sun.management.jmxremote=true " +
+		"-Dcom.sun.management.jmxremote.port=9010 -cp /opt/kafka/libs/* kafka.Kafka /opt/kafka/config/server.properties",
+	"java -jar /opt/app/app.jar --server.port=8080",
+	"sudo -u postgres /usr/bin/psql -h localhost",
+	"LANG=C nohup /usr/bin/redis-server 127.0.0.1:6379",
+	"/usr/bin/dockerd -H fd:// --containerd=/run/containerd/containerd.sock",
+	"node /srv/app/server.js --port 3000",
+	`/bin/sh -c "exec /usr/bin/app --config '/etc/app/app.conf'"`,
+}
+
+func BenchmarkParseCommandLine(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		for _, s := range benchCmdlines {
+			ParseCommandLine(false, s)
+		}
+	}
+}
+
+func BenchmarkParse(b *testing.B) {
+	argvs := make([][]string, 0, len(benchCmdlines))
+	for _, s := range benchCmdlines {
+		c, err := ParseCommandLine(false, s)
+		if err != nil {
+			b.Fatal(err)
+		}
+		argvs = append(argvs, c.Argv())
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, argv := range argvs {
+			Parse(false, argv[0], argv[1:])
+		}
+	}
+}
+
+func BenchmarkParseCommandLineCached(b *testing.B) {
+	p := NewParser(WithCache(NewCache(len(benchCmdlines))))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		for _, s := range benchCmdlines {
+			p.ParseCommandLine(s)
+		}
+	}
+}
+
+func BenchmarkSplitVersion(b *testing.B) {
+	names := []string{"python3.11", "ruby", "java", "php8.2-fpm", "node18"}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		for _, name := range names {
+			splitVersion(name)
+		}
+	}
+}
@@ -0,0 +1,223 @@
+package cmdline
+
+import (
+	"container/list"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Cache keeps the results of parsers for the command lines seen again, like
+// the ones of a process scan every few seconds. It is safe for concurrent
+// use and may be shared by parsers with different options.
+//
+// The cache keeps its own copy of the results, every CommandLine a parser
+// returns is a deep copy the caller may change without changing the cache.
+type Cache struct {
+	mu         sync.Mutex
+	maxEntries int
+	entries    map[string]*list.Element
+	lru        *list.List
+	stats      CacheStats
+}
+
+// CacheStats are the counters of a Cache
+type CacheStats struct {
+	Hits      uint64
+	Misses    uint64
+	Evictions uint64
+	// Entries is the number of results in the cache
+	Entries int
+}
+
+type cacheEntry struct {
+	key     string
+	cmdline *CommandLine
+	err     error
+}
+
+// NewCache returns a cache that keeps the results of the maxEntries command
+// lines used last, maxEntries below 1 is taken as 1
+func NewCache(maxEntries int) *Cache {
+	if maxEntries < 1 {
+		maxEntries = 1
+	}
+	return &Cache{
+		maxEntries: maxEntries,
+		entries:    map[string]*list.Element{},
+		lru:        list.New(),
+	}
+}
+
+// WithCache lets the parser look up and keep its results in cache, the
+// results are cached by command line and by the options of the parser.
+// Nothing is logged for the results found in the cache.
+//
+// The file system of WithFS is told apart by the WithFS call it comes from,
+// not by its files. The results go stale when the executables or scripts in
+// it change, Purge drops them. A registry is told apart by a number that
+// changes with every Register, the parsers made after a change don't get the
+// results of the ones made before.
+func WithCache(cache *Cache) Option {
+	return func(p *Parser) {
+		p.cache = cache
+	}
+}
+
+// Stats returns the counters of the cache
+func (c *Cache) Stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stats := c.stats
+	stats.Entries = c.lru.Len()
+	return stats
+}
+
+// Purge removes all the results, the counters are kept
+func (c *Cache) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = map[string]*list.Element{}
+	c.lru.Init()
+}
+
+// get returns a copy of the result of key, parse makes it on a miss. Two
+// parsers missing the same key at once both parse, the last one is kept.
+func (c *Cache) get(key string, parse func() (*CommandLine, error)) (*CommandLine, error) {
+	c.mu.Lock()
+	if elem, ok := c.entries[key]; ok {
+		c.lru.MoveToFront(elem)
+		c.stats.Hits++
+		entry := elem.Value.(*cacheEntry)
+		c.mu.Unlock()
+		return entry.cmdline.Clone(), entry.err
+	}
+	c.stats.Misses++
+	c.mu.Unlock()
+
+	cmdline, err := parse()
+	entry := &cacheEntry{key: key, cmdline: cmdline.Clone(), err: err}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.entries[key]; ok {
+		elem.Value = entry
+		c.lru.MoveToFront(elem)
+		return cmdline, err
+	}
+	c.entries[key] = c.lru.PushFront(entry)
+	for c.lru.Len() > c.maxEntries {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).key)
+		c.stats.Evictions++
+	}
+	return cmdline, err
+}
+
+// cacheKeyPrefix tells the options of the parser apart in the keys of the
+// cache, the logger doesn't change the results
+func (p *Parser) cacheKeyPrefix() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d|%d|%d|%d|%q|%d|", p.flavor, p.strictness, p.maxDepth, p.registry.id, p.workingDir, p.fsysID)
+	if p.env != nil {
+		names := make([]string, 0, len(p.env))
+		for name := range p.env {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		b.WriteByte('$')
+		for _, name := range names {
+			b.WriteString(name)
+			b.WriteByte('=')
+			b.WriteString(p.env[name])
+			b.WriteByte(0)
+		}
+	}
+	return b.String()
+}
+
+// Clone returns a deep copy of the command line, the Args of Sub, Python and
+// the others stay the tails of Args
+func (c *CommandLine) Clone() *CommandLine {
+	if c == nil {
+		return nil
+	}
+
+	clone := *c
+	clone.Env = cloneStrings(c.Env)
+	clone.Args = cloneStrings(c.Args)
+	if c.Expansions != nil {
+		clone.Expansions = append([]Expansion{}, c.Expansions...)
+	}
+	if c.Diagnostics != nil {
+		clone.Diagnostics = append([]Diagnostic{}, c.Diagnostics...)
+	}
+	if c.Resolved != nil {
+		resolved := *c.Resolved
+		resolved.Links = cloneStrings(c.Resolved.Links)
+		clone.Resolved = &resolved
+	}
+	if c.Runtime != nil {
+		runtime := *c.Runtime
+		clone.Runtime = &runtime
+	}
+
+	if c.Sub != nil {
+		sub := *c.Sub
+		sub.Args = cloneTail(c.Args, clone.Args, c.Sub.Args)
+		sub.CommandLine = c.Sub.CommandLine.Clone()
+		clone.Sub = &sub
+	}
+	if c.Ruby != nil {
+		ruby := *c.Ruby
+		ruby.Args = cloneTail(c.Args, clone.Args, c.Ruby.Args)
+		clone.Ruby = &ruby
+	}
+	if c.Python != nil {
+		python := *c.Python
+		python.Args = cloneTail(c.Args, clone.Args, c.Python.Args)
+		clone.Python = &python
+	}
+	if c.Java != nil {
+		java := *c.Java
+		java.Args = cloneTail(c.Args, clone.Args, c.Java.Args)
+		clone.Java = &java
+	}
+	if c.Windows != nil {
+		windows := *c.Windows
+		windows.Args = cloneTail(c.Args, clone.Args, c.Windows.Args)
+		clone.Windows = &windows
+	}
+	if c.Custom != nil {
+		custom := *c.Custom
+		if c.Custom.Values != nil {
+			custom.Values = append([]RoleValue{}, c.Custom.Values...)
+		}
+		clone.Custom = &custom
+	}
+	return &clone
+}
+
+func cloneStrings(s []string) []string {
+	if s == nil {
+		return nil
+	}
+	return append(make([]string, 0, len(s)), s...)
+}
+
+// cloneTail copies rest, as the tail of clonedArgs when it is the tail of
+// args
+func cloneTail(args, clonedArgs, rest []string) []string {
+	if rest == nil {
+		return nil
+	}
+	if start := len(args) - len(rest); start >= 0 && clonedArgs != nil {
+		tail := args[start:]
+		if len(tail) == 0 || &tail[0] == &rest[0] {
+			return clonedArgs[start:len(clonedArgs):len(clonedArgs)]
+		}
+	}
+	return cloneStrings(rest)
+}
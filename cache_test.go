@@ -0,0 +1,172 @@
+package cmdline
+
+import (
+	"sync"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCache(t *testing.T) {
+	cache := NewCache(2)
+	p := NewParser(WithCache(cache), WithMaxDepth(1))
+
+	const cmdline = "sudo -u dog java -Dcom.sun.management.jmxremote.port=9010 -jar app.jar --port 80"
+	expected, err := NewParser(WithMaxDepth(1)).ParseCommandLine(cmdline)
+	assert.NoError(t, err)
+
+	first, err := p.ParseCommandLine(cmdline)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, first)
+	assert.Equal(t, CacheStats{Misses: 1, Entries: 1}, cache.Stats())
+
+	// the caller owns the result, changing it doesn't change the cache
+	first.Args[0] = "-n"
+	first.Sub.Args[0] = "--changed"
+	first.Sub.CommandLine.Java.JmxPort = "1"
+	first.Sub.CommandLine.Args = nil
+
+	second, err := p.ParseCommandLine(cmdline)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, second)
+	assert.Equal(t, CacheStats{Hits: 1, Misses: 1, Entries: 1}, cache.Stats())
+
+	// errors are cached too
+	_, err = p.ParseCommandLine(`python -c "print(1)"`)
+	assert.ErrorIs(t, err, ErrNoScript)
+	c, err := p.ParseCommandLine(`python -c "print(1)"`)
+	assert.ErrorIs(t, err, ErrNoScript)
+	assert.NotNil(t, c)
+	assert.Equal(t, CacheStats{Hits: 2, Misses: 2, Entries: 2}, cache.Stats())
+
+	// Parse and ParseCommandLine don't share their entries
+	_, err = p.Parse("nginx", []string{"-g", "daemon off;"})
+	assert.NoError(t, err)
+	assert.Equal(t, CacheStats{Hits: 2, Misses: 3, Evictions: 1, Entries: 2}, cache.Stats())
+
+	// the sudo command line was used last, it is the one evicted
+	_, err = p.ParseCommandLine(cmdline)
+	assert.NoError(t, err)
+	assert.Equal(t, CacheStats{Hits: 2, Misses: 4, Evictions: 2, Entries: 2}, cache.Stats())
+
+	// parsers with other options have their own entries
+	windows := NewParser(WithCache(cache), WithFlavor(FlavorWindows))
+	c, err = windows.ParseCommandLine(cmdline)
+	assert.NoError(t, err)
+	assert.Nil(t, c.Sub.CommandLine)
+	assert.Equal(t, uint64(5), cache.Stats().Misses)
+
+	env := NewParser(WithCache(cache), WithEnv(map[string]string{"PORT": "80"}))
+	c, err = env.ParseCommandLine("app --port $PORT")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"--port", "80"}, c.Args)
+	env = NewParser(WithCache(cache), WithEnv(map[string]string{"PORT": "81"}))
+	c, err = env.ParseCommandLine("app --port $PORT")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"--port", "81"}, c.Args)
+	assert.Equal(t, uint64(7), cache.Stats().Misses)
+
+	cache.Purge()
+	assert.Equal(t, CacheStats{Hits: 2, Misses: 7, Evictions: 5}, cache.Stats())
+}
+
+func TestCacheFS(t *testing.T) {
+	cache := NewCache(8)
+	fsys := fstest.MapFS{"usr/local/bin/app": script("#!/usr/bin/python3")}
+	p := NewParser(WithCache(cache), WithFS(fsys))
+
+	c, err := p.ParseCommandLine("/usr/local/bin/app --port 80")
+	assert.NoError(t, err)
+	assert.Equal(t, "/usr/bin/python3", c.ExecutePath)
+
+	// the file system is told apart by its WithFS, not by its files
+	c, err = NewParser(WithCache(cache), WithFS(fsys)).ParseCommandLine("/usr/local/bin/app --port 80")
+	assert.NoError(t, err)
+	assert.Equal(t, "/usr/bin/python3", c.ExecutePath)
+	assert.Equal(t, CacheStats{Misses: 2, Entries: 2}, cache.Stats())
+
+	// the results go stale when the files change, until a purge
+	fsys["usr/local/bin/app"] = script("#!/usr/bin/ruby")
+	c, err = p.ParseCommandLine("/usr/local/bin/app --port 80")
+	assert.NoError(t, err)
+	assert.Equal(t, "/usr/bin/python3", c.ExecutePath)
+	cache.Purge()
+	c, err = p.ParseCommandLine("/usr/local/bin/app --port 80")
+	assert.NoError(t, err)
+	assert.Equal(t, "/usr/bin/ruby", c.ExecutePath)
+}
+
+func TestCacheRegistry(t *testing.T) {
+	cache := NewCache(8)
+	registry := NewRegistry()
+	c, err := NewParser(WithCache(cache), WithRegistry(registry)).ParseCommandLine("dog --port 80")
+	assert.NoError(t, err)
+	assert.Nil(t, c.Custom)
+
+	registry.Register("dog", func(cmdline *CommandLine) error {
+		cmdline.Custom = &CustomArgs{Rule: "dog"}
+		return nil
+	})
+	c, err = NewParser(WithCache(cache), WithRegistry(registry)).ParseCommandLine("dog --port 80")
+	assert.NoError(t, err)
+	assert.Equal(t, &CustomArgs{Rule: "dog"}, c.Custom)
+
+	// the registries are not told apart by their address
+	c, err = NewParser(WithCache(cache), WithRegistry(NewRegistry())).ParseCommandLine("dog --port 80")
+	assert.NoError(t, err)
+	assert.Nil(t, c.Custom)
+	assert.Equal(t, CacheStats{Misses: 3, Entries: 3}, cache.Stats())
+}
+
+func TestCacheConcurrency(t *testing.T) {
+	cache := NewCache(8)
+	p := NewParser(WithCache(cache))
+	cmdlines := []string{
+		"java -jar app.jar",
+		"python3 -m http.server 8000",
+		"nginx -g 'daemon off;'",
+		"ruby bin/server -p 80",
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				c, err := p.ParseCommandLine(cmdlines[j%len(cmdlines)])
+				if assert.NoError(t, err) {
+					c.Args = append(c.Args[:0], "changed")
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	stats := cache.Stats()
+	assert.Equal(t, uint64(800), stats.Hits+stats.Misses)
+	assert.Equal(t, len(cmdlines), stats.Entries)
+	c, err := p.ParseCommandLine(cmdlines[0])
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"-jar", "app.jar"}, c.Args)
+}
+
+func TestClone(t *testing.T) {
+	c, err := NewParser(WithMaxDepth(1)).ParseCommandLine("LANG=C sudo python3 app.py --port 80")
+	assert.NoError(t, err)
+
+	clone := c.Clone()
+	assert.Equal(t, c, clone)
+	assert.Equal(t, clone.Args[1:], clone.Sub.Args)
+	clone.Args[1] = "main.py"
+	assert.Equal(t, "main.py", clone.Sub.Args[0])
+	assert.Equal(t, "app.py", c.Sub.Args[0])
+
+	clone.Sub.CommandLine.Python.Args[0] = "--host"
+	clone.Env[0] = "LANG=en"
+	assert.Equal(t, "--port", c.Sub.CommandLine.Python.Args[0])
+	assert.Equal(t, "LANG=C", c.Env[0])
+
+	assert.Nil(t, (*CommandLine)(nil).Clone())
+}
@@ -0,0 +1,535 @@
+// Command cmdline parses command lines the way the cmdline package does.
+//
+//	cmdline parse [--windows] [--argv] [--rules file] [--root dir] [--explain] [--output json|yaml|table|tokens] [command line...]
+//	cmdline name  [--windows] [--argv] [--rules file] [--root dir] [--explain] [--output json|yaml|table] [command line...]
+//	cmdline proc  [--procfs /proc] [--rules file] [--root dir] [--env] [--output json|yaml|table] <pid>...
+//	cmdline scan  [--procfs /proc] [--rules file] [--root dir] [--env] [--match expression] [--output json|yaml|table]
+//	cmdline diff  [--windows] [--output json|yaml|table] <command line> <command line>
+//
+// parse and name read one command line per line from stdin when no command
+// line is given, --rules loads a rule file, see cmdline.Rule, --root follows
+// the symbolic links of the executables and the "#!" line of the scripts in
+// the file system under dir, and --explain traces to stderr which extractor
+// matched and the arguments it skipped. proc and scan take --rules and
+// --root too, --env expands the command lines with the environment of the
+// processes. parse --output tokens prints the role of every token of the
+// command lines and of the commands they wrap, in colour on a terminal. scan
+// --match only lists the processes matching the expression, see
+// cmdline.CompileMatcher. diff prints what the second command line runs
+// differently from the first, see cmdline.Diff. The exit code is 1 when a
+// command line fails to parse or diff finds a change, and 2 on bad usage.
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/mei-rune/cmdline"
+	"gopkg.in/yaml.v3"
+)
+
+const usage = `usage: cmdline <command> [options] [arguments]
+
+commands:
+  parse   parse command lines, from the arguments or stdin
+  name    print the service name of command lines, from the arguments or stdin
+  proc    parse the command line of processes by pid
+  scan    parse the command line of all processes
+  diff    compare what two command lines run
+`
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+type record struct {
+	PID         int                  `json:"pid,omitempty" yaml:"pid,omitempty"`
+	PPID        int                  `json:"ppid,omitempty" yaml:"ppid,omitempty"`
+	Name        string               `json:"name" yaml:"name"`
+	CommandLine *cmdline.CommandLine `json:"commandline,omitempty" yaml:"commandline,omitempty"`
+	Error       string               `json:"error,omitempty" yaml:"error,omitempty"`
+
+	// Tokens are the roles of the tokens for --output tokens
+	Tokens []cmdline.TokenAnnotation `json:"-" yaml:"-"`
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return 2
+	}
+
+	fs := flag.NewFlagSet("cmdline "+args[0], flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	output := fs.String("output", "table", "output format: json, yaml or table")
+
+	var (
+		isWindows, isArgv *bool
+		explain           *bool
+		processEnv        *bool
+		procfs, match     *string
+		rules, root       *string
+	)
+	switch args[0] {
+	case "parse", "name":
+		isWindows = fs.Bool("windows", false, "parse with the windows rules")
+		isArgv = fs.Bool("argv", false, "the arguments are the tokens, not a command line string")
+		rules = fs.String("rules", "", "a rule file with more executables")
+		root = fs.String("root", "", "resolve the links and scripts of the executables in the file system under the directory")
+		explain = fs.Bool("explain", false, "trace how the command lines are read to stderr")
+	case "diff":
+		isWindows = fs.Bool("windows", false, "parse with the windows rules")
+	case "proc", "scan":
+		procfs = fs.String("procfs", cmdline.DefaultProcfs, "where procfs is mounted")
+		rules = fs.String("rules", "", "a rule file with more executables")
+		root = fs.String("root", "", "resolve the links and scripts of the executables in the file system under the directory")
+		processEnv = fs.Bool("env", false, "expand the command lines with the environment of the processes")
+		if args[0] == "scan" {
+			match = fs.String("match", "", "only the processes matching the expression")
+		}
+	case "-h", "-help", "--help", "help":
+		fmt.Fprint(stdout, usage)
+		return 0
+	default:
+		fmt.Fprintf(stderr, "unknown command %q\n%s", args[0], usage)
+		return 2
+	}
+	if err := fs.Parse(args[1:]); err != nil {
+		return 2
+	}
+	if args[0] == "diff" {
+		return runDiff(fs.Args(), *isWindows, *output, stdout, stderr)
+	}
+
+	explained := *output == "tokens"
+	if explained && (args[0] != "parse" || *isArgv) {
+		fmt.Fprintln(stderr, "--output tokens is for parse without --argv")
+		return 2
+	}
+	w, err := newWriter(*output, args[0] == "name", stdout)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+
+	failed := false
+	emit := func(r *record, err error) {
+		if err != nil {
+			failed = true
+			r.Error = err.Error()
+			fmt.Fprintln(stderr, err)
+		}
+		if r.CommandLine != nil {
+			r.Name = r.CommandLine.ServiceName()
+		}
+		if err := w.Write(r); err != nil {
+			fmt.Fprintln(stderr, err)
+			failed = true
+		}
+	}
+
+	switch args[0] {
+	case "parse", "name":
+		opts, err := parserOptions(*rules, *root)
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return 2
+		}
+		if *isWindows {
+			opts = append(opts, cmdline.WithFlavor(cmdline.FlavorWindows))
+		}
+		if *explain {
+			opts = append(opts, cmdline.WithLogger(explainLogger(stderr)))
+		}
+		if explained {
+			// the wrapped commands are explained too
+			opts = append(opts, cmdline.WithMaxDepth(tokensDepth))
+		}
+		p := cmdline.NewParser(opts...)
+		parse := func(s string) (*record, error) {
+			if explained {
+				c, tokens, err := p.ParseExplained(s)
+				return &record{CommandLine: c, Tokens: tokens}, err
+			}
+			c, err := p.ParseCommandLine(s)
+			return &record{CommandLine: c}, err
+		}
+
+		if fs.NArg() > 0 {
+			if *isArgv {
+				c, err := p.Parse(fs.Arg(0), fs.Args()[1:])
+				emit(&record{CommandLine: c}, err)
+				break
+			}
+			emit(parse(strings.Join(fs.Args(), " ")))
+			break
+		}
+		if *isArgv {
+			fmt.Fprintln(stderr, "--argv needs the tokens as arguments")
+			return 2
+		}
+
+		scanner := bufio.NewScanner(stdin)
+		scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+		for scanner.Scan() {
+			line := scanner.Text()
+			if strings.TrimSpace(line) == "" {
+				continue
+			}
+			r, err := parse(line)
+			if err != nil {
+				err = fmt.Errorf("%s: %w", line, err)
+			}
+			emit(r, err)
+		}
+		if err := scanner.Err(); err != nil {
+			fmt.Fprintln(stderr, err)
+			failed = true
+		}
+	case "proc":
+		if fs.NArg() == 0 {
+			fmt.Fprintln(stderr, "proc needs a pid")
+			return 2
+		}
+		p, err := processParser(*rules, *root, *processEnv)
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return 2
+		}
+		for _, s := range fs.Args() {
+			pid, err := strconv.Atoi(s)
+			if err != nil {
+				fmt.Fprintf(stderr, "invalid pid %q\n", s)
+				return 2
+			}
+			proc, err := p.ReadProcess(*procfs, pid)
+			if proc == nil {
+				emit(&record{PID: pid}, err)
+				continue
+			}
+			emit(&record{PID: proc.PID, PPID: proc.PPID, CommandLine: proc.CommandLine}, err)
+		}
+	case "scan":
+		p, err := processParser(*rules, *root, *processEnv)
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return 2
+		}
+		var matcher cmdline.Matcher
+		if *match != "" {
+			if matcher, err = cmdline.CompileMatcher(*match); err != nil {
+				fmt.Fprintln(stderr, err)
+				return 2
+			}
+		}
+
+		processes, err := p.ScanProcesses(*procfs)
+		for _, proc := range processes {
+			if matcher != nil && !matcher.Match(proc.CommandLine) {
+				continue
+			}
+			emit(&record{PID: proc.PID, PPID: proc.PPID, CommandLine: proc.CommandLine}, nil)
+		}
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			failed = true
+		}
+	}
+
+	if err := w.Flush(); err != nil {
+		fmt.Fprintln(stderr, err)
+		failed = true
+	}
+	if failed {
+		return 1
+	}
+	return 0
+}
+
+// parserOptions returns the options of the --rules and --root flags
+func parserOptions(rules, root string) ([]cmdline.Option, error) {
+	registry := cmdline.NewRegistry()
+	if rules != "" {
+		if err := registry.LoadRuleFile(rules); err != nil {
+			return nil, err
+		}
+	}
+	opts := []cmdline.Option{cmdline.WithRegistry(registry)}
+	if root != "" {
+		opts = append(opts, cmdline.WithFS(cmdline.DirFS(root)))
+	}
+	return opts, nil
+}
+
+// processParser returns the parser of proc and scan
+func processParser(rules, root string, processEnv bool) (*cmdline.Parser, error) {
+	opts, err := parserOptions(rules, root)
+	if err != nil {
+		return nil, err
+	}
+	if processEnv {
+		opts = append(opts, cmdline.WithProcessEnv())
+	}
+	return cmdline.NewParser(opts...), nil
+}
+
+// runDiff prints the changes from the command line a to b, one per line
+// for json and table
+func runDiff(args []string, isWindows bool, output string, stdout, stderr io.Writer) int {
+	if len(args) != 2 {
+		fmt.Fprintln(stderr, "diff needs two command lines")
+		return 2
+	}
+	if output != "json" && output != "yaml" && output != "table" {
+		fmt.Fprintf(stderr, "unknown output format %q, want json, yaml or table\n", output)
+		return 2
+	}
+
+	a, err := cmdline.ParseCommandLine(isWindows, args[0])
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	b, err := cmdline.ParseCommandLine(isWindows, args[1])
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+
+	changes := cmdline.Diff(a, b)
+	switch output {
+	case "json":
+		encoder := json.NewEncoder(stdout)
+		for _, change := range changes {
+			if err = encoder.Encode(change); err != nil {
+				break
+			}
+		}
+	case "yaml":
+		if len(changes) > 0 {
+			err = yaml.NewEncoder(stdout).Encode(changes)
+		}
+	case "table":
+		for _, change := range changes {
+			if _, err = fmt.Fprintln(stdout, change); err != nil {
+				break
+			}
+		}
+	}
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	if len(changes) > 0 {
+		return 1
+	}
+	return 0
+}
+
+type writer interface {
+	Write(r *record) error
+	Flush() error
+}
+
+func newWriter(format string, nameOnly bool, out io.Writer) (writer, error) {
+	switch format {
+	case "json":
+		return &jsonWriter{encoder: json.NewEncoder(out), nameOnly: nameOnly}, nil
+	case "yaml":
+		return &yamlWriter{encoder: yaml.NewEncoder(out), nameOnly: nameOnly}, nil
+	case "table":
+		return &tableWriter{w: tabwriter.NewWriter(out, 0, 4, 2, ' ', 0), nameOnly: nameOnly}, nil
+	case "tokens":
+		return &tokensWriter{w: tabwriter.NewWriter(out, 0, 4, 2, ' ', 0), color: isTerminal(out)}, nil
+	}
+	return nil, errors.New("unknown output format " + strconv.Quote(format) + ", want json, yaml, table or tokens")
+}
+
+type nameRecord struct {
+	PID   int    `json:"pid,omitempty" yaml:"pid,omitempty"`
+	Name  string `json:"name" yaml:"name"`
+	Error string `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+// jsonWriter writes a json object per line, so that batches can be streamed
+type jsonWriter struct {
+	encoder  *json.Encoder
+	nameOnly bool
+}
+
+func (w *jsonWriter) Write(r *record) error {
+	if w.nameOnly {
+		return w.encoder.Encode(nameRecord{PID: r.PID, Name: r.Name, Error: r.Error})
+	}
+	return w.encoder.Encode(r)
+}
+
+func (w *jsonWriter) Flush() error {
+	return nil
+}
+
+// yamlWriter writes a yaml document per record
+type yamlWriter struct {
+	encoder  *yaml.Encoder
+	nameOnly bool
+}
+
+func (w *yamlWriter) Write(r *record) error {
+	if w.nameOnly {
+		return w.encoder.Encode(nameRecord{PID: r.PID, Name: r.Name, Error: r.Error})
+	}
+	return w.encoder.Encode(r)
+}
+
+func (w *yamlWriter) Flush() error {
+	return w.encoder.Close()
+}
+
+type tableWriter struct {
+	w        *tabwriter.Writer
+	nameOnly bool
+	header   bool
+}
+
+func (w *tableWriter) Write(r *record) error {
+	if w.nameOnly {
+		_, err := fmt.Fprintln(w.w, r.Name)
+		return err
+	}
+
+	if !w.header {
+		w.header = true
+		if _, err := fmt.Fprintln(w.w, "PID\tPPID\tNAME\tRUNTIME\tTARGET\tEXECUTABLE"); err != nil {
+			return err
+		}
+	}
+
+	var pid, ppid, runtime, target, exe string
+	if r.PID != 0 {
+		pid, ppid = strconv.Itoa(r.PID), strconv.Itoa(r.PPID)
+	}
+	if c := r.CommandLine; c != nil {
+		exe = c.ExecutePath
+		switch {
+		case c.Java != nil:
+			runtime, target = cmdline.RuntimeJava, c.Java.ClassName
+		case c.Python != nil:
+			runtime, target = cmdline.RuntimePython, c.Python.FilePath
+		case c.Ruby != nil:
+			runtime, target = cmdline.RuntimeRuby, c.Ruby.FilePath
+		case c.Sub != nil:
+			runtime, target = cmdline.RuntimeCommand, c.Sub.Command
+		case c.Windows != nil:
+			runtime, target = cmdline.RuntimeWindows, c.Windows.Host
+		}
+	}
+	_, err := fmt.Fprintf(w.w, "%s\t%s\t%s\t%s\t%s\t%s\n", pid, ppid, r.Name, runtime, target, exe)
+	return err
+}
+
+func (w *tableWriter) Flush() error {
+	return w.w.Flush()
+}
+
+// explainLogger writes the trace of the parser without the times
+func explainLogger(w io.Writer) *slog.Logger {
+	return slog.New(slog.NewTextHandler(w, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if len(groups) == 0 && (a.Key == slog.TimeKey || a.Key == slog.LevelKey) {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}))
+}
+
+// tokensDepth is how many wrappers deep --output tokens goes
+const tokensDepth = 8
+
+// isTerminal tells if out is a terminal that takes colours
+func isTerminal(out io.Writer) bool {
+	f, ok := out.(*os.File)
+	if !ok || os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	st, err := f.Stat()
+	return err == nil && st.Mode()&os.ModeCharDevice != 0
+}
+
+// roleColors are the ANSI colours of the roles, the program arguments keep
+// the default one
+var roleColors = map[cmdline.TokenRole]string{
+	cmdline.TokenEnv:           "36",
+	cmdline.TokenExecutable:    "1",
+	cmdline.TokenWrapperOption: "33",
+	cmdline.TokenRuntimeOption: "34",
+	cmdline.TokenOptionValue:   "2",
+	cmdline.TokenEndOfOptions:  "2",
+	cmdline.TokenTarget:        "1;32",
+}
+
+// tokensWriter writes a table of the tokens of every command line, the
+// roles are the last column so that the colours don't break the alignment
+type tokensWriter struct {
+	w     *tabwriter.Writer
+	color bool
+	count int
+}
+
+func (w *tokensWriter) Write(r *record) error {
+	if w.count > 0 {
+		if _, err := fmt.Fprintln(w.w); err != nil {
+			return err
+		}
+	}
+	w.count++
+
+	if _, err := fmt.Fprintln(w.w, "#\tEXECUTABLE\tTOKEN\tROLE"); err != nil {
+		return err
+	}
+	for idx, t := range r.Tokens {
+		token := t.Token
+		if token == "" || strings.ContainsAny(token, "\t\r\n") {
+			token = strconv.Quote(token)
+		}
+
+		role := t.Role.String()
+		if t.Unknown {
+			role = "unknown " + role
+		}
+		if t.Option != "" {
+			role += " of " + t.Option
+		}
+		if code := roleColors[t.Role]; w.color && (code != "" || t.Unknown) {
+			if t.Unknown {
+				code = "31"
+			}
+			role = "\x1b[" + code + "m" + role + "\x1b[0m"
+		}
+
+		_, err := fmt.Fprintf(w.w, "%d\t%s\t%s%s\t%s\n", idx, t.Executable, strings.Repeat("  ", t.Depth), token, role)
+		if err != nil {
+			return err
+		}
+	}
+	if r.Error != "" {
+		if _, err := fmt.Fprintf(w.w, "error: %s\n", r.Error); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *tokensWriter) Flush() error {
+	return w.w.Flush()
+}
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"text/tabwriter"
+
+	"github.com/mei-rune/cmdline"
+	"github.com/stretchr/testify/assert"
+)
+
+func runCommand(stdin string, args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	code := run(args, strings.NewReader(stdin), &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestParse(t *testing.T) {
+	code, stdout, _ := runCommand("", "parse", "--output", "json", "python3", "-m", "flask", "run")
+	assert.Equal(t, 0, code)
+
+	var r struct {
+		Name        string `json:"name"`
+		CommandLine struct {
+			Runtime string   `json:"runtime"`
+			Args    []string `json:"args"`
+		} `json:"commandline"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &r); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "flask", r.Name)
+	assert.Equal(t, "python", r.CommandLine.Runtime)
+	assert.Equal(t, []string{"-m", "flask", "run"}, r.CommandLine.Args)
+
+	code, stdout, _ = runCommand("", "parse", "--argv", "--output", "yaml", "/opt/my app/ruby", "/srv/app server.rb")
+	assert.Equal(t, 0, code)
+	assert.Contains(t, stdout, "file_path: /srv/app server.rb")
+
+	code, stdout, _ = runCommand("", "parse", "--windows", `C:\Windows\system32\svchost.exe -k netsvcs -s Schedule`)
+	assert.Equal(t, 0, code)
+	assert.Contains(t, stdout, "Schedule")
+	assert.Contains(t, stdout, "windows")
+
+	code, stdout, _ = runCommand("", "parse", "--rules", "../../testdata/rules/acme.yaml", "--output", "yaml", "acme-agent --conf x.yml run")
+	assert.Equal(t, 0, code)
+	assert.Contains(t, stdout, "rule: acme-agent")
+
+	code, _, stderr := runCommand("", "parse", "--rules", "missing.yaml", "app")
+	assert.Equal(t, 2, code)
+	assert.Contains(t, stderr, "missing.yaml")
+
+	code, stdout, stderr = runCommand("", "name", "--explain", "sudo", "LANG=C", "python3", "-u", "app.py")
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "python3\n", stdout)
+	assert.Contains(t, stderr, `msg="extractor matched" executable=sudo depth=0`)
+	assert.Contains(t, stderr, `msg="argument skipped" executable=sudo depth=0 index=0 arg="LANG=C" reason="environment assignment"`)
+	assert.NotContains(t, stderr, "time=")
+}
+
+func TestParseRoot(t *testing.T) {
+	root := t.TempDir()
+	bin := filepath.Join(root, "usr", "bin")
+	assert.NoError(t, os.MkdirAll(bin, 0o755))
+	assert.NoError(t, os.WriteFile(filepath.Join(bin, "python3.9"), nil, 0o755))
+	if err := os.Symlink("python3.9", filepath.Join(bin, "py")); err != nil {
+		t.Skip("no symbolic links:", err)
+	}
+
+	code, stdout, _ := runCommand("", "parse", "--root", root, "--output", "yaml", "/usr/bin/py -m http.server")
+	assert.Equal(t, 0, code)
+	assert.Contains(t, stdout, "path: /usr/bin/python3.9")
+	assert.Contains(t, stdout, "file_path: http.server")
+
+	assert.NoError(t, os.WriteFile(filepath.Join(bin, "celery"), []byte("#!/usr/bin/py\n"), 0o755))
+	code, stdout, _ = runCommand("", "name", "--root", root, "/usr/bin/celery worker")
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "celery\n", stdout)
+}
+
+func TestParseTokens(t *testing.T) {
+	code, stdout, _ := runCommand("", "parse", "--output", "tokens", "sudo -u dog java -Xmx1g -jar app.jar --debug")
+	assert.Equal(t, 0, code)
+	assert.Equal(t, `#  EXECUTABLE  TOKEN      ROLE
+0  sudo        sudo       executable
+1  sudo        -u         wrapper option
+2  sudo        dog        option value of -u
+3  java          java     executable
+4  java          -Xmx1g   runtime option
+5  java          -jar     runtime option
+6  java          app.jar  main target of -jar
+7  java          --debug  program argument
+`, stdout)
+
+	code, stdout, _ = runCommand("python3 -c 'print(1)'\nnginx -t\n", "parse", "--output", "tokens")
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stdout, "2  python3     print(1)  option value of -c\n")
+	assert.Contains(t, stdout, "error: python3 -c 'print(1)': ")
+	assert.Contains(t, stdout, "\n\n#  EXECUTABLE  TOKEN")
+	assert.Contains(t, stdout, "1  nginx       -t     program argument\n")
+
+	var buf bytes.Buffer
+	w := &tokensWriter{w: tabwriter.NewWriter(&buf, 0, 4, 2, ' ', 0), color: true}
+	c, tokens, _ := cmdline.ParseExplained(false, "java --frobnicate Main")
+	assert.NoError(t, w.Write(&record{CommandLine: c, Tokens: tokens}))
+	assert.NoError(t, w.Flush())
+	assert.Contains(t, buf.String(), "\x1b[31munknown runtime option\x1b[0m")
+	assert.Contains(t, buf.String(), "\x1b[1;32mmain target\x1b[0m")
+
+	code, _, _ = runCommand("", "name", "--output", "tokens", "app")
+	assert.Equal(t, 2, code)
+	code, _, _ = runCommand("", "parse", "--argv", "--output", "tokens", "app")
+	assert.Equal(t, 2, code)
+}
+
+func TestParseStdin(t *testing.T) {
+	stdin := "java -jar /opt/app.jar\n\nsudo -u dog /usr/local/bin/myApp\npython3 -u\n"
+	code, stdout, stderr := runCommand(stdin, "name")
+	assert.Equal(t, 1, code)
+	assert.Equal(t, "app\nmyApp\npython3\n", stdout)
+	assert.Contains(t, stderr, "python3 -u")
+
+	code, stdout, _ = runCommand("ruby a.rb\nruby b.rb\n", "name", "--output", "json")
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "{\"name\":\"a\"}\n{\"name\":\"b\"}\n", stdout)
+
+	code, _, _ = runCommand("a 'b\n", "parse")
+	assert.Equal(t, 1, code)
+}
+
+func TestProcAndScan(t *testing.T) {
+	root := t.TempDir()
+	for pid, files := range map[string][2]string{
+		"1":   {"/sbin/init\x00", "1 (init) S 0 1 1"},
+		"200": {"ruby\x00/usr/sbin/td-agent\x00", "200 (ruby) S 1 200 200"},
+		"201": {"acme-agent\x00--conf\x00$CONF\x00run\x00", "201 (acme-agent) S 1 201 201"},
+		// a python REPL doesn't fail the scan
+		"202": {"python3\x00", "202 (python3) S 1 202 202"},
+	} {
+		if err := os.MkdirAll(filepath.Join(root, pid), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(root, pid, "cmdline"), []byte(files[0]), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(root, pid, "stat"), []byte(files[1]), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := os.WriteFile(filepath.Join(root, "201", "environ"), []byte("CONF=/etc/acme.yml\x00"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	code, stdout, _ := runCommand("", "proc", "--procfs", root, "--output", "json", "200")
+	assert.Equal(t, 0, code)
+	assert.Contains(t, stdout, `"pid":200,"ppid":1,"name":"td-agent"`)
+
+	code, stdout, _ = runCommand("", "scan", "--procfs", root)
+	assert.Equal(t, 0, code)
+	lines := strings.Split(strings.TrimSpace(stdout), "\n")
+	assert.Equal(t, 5, len(lines))
+	assert.True(t, strings.HasPrefix(lines[0], "PID"))
+	assert.Contains(t, lines[2], "td-agent")
+
+	code, stdout, _ = runCommand("", "scan", "--procfs", root, "--match", `runtime == "ruby"`, "--output", "json")
+	assert.Equal(t, 0, code)
+	assert.Equal(t, 1, strings.Count(stdout, "\n"))
+	assert.Contains(t, stdout, `"name":"td-agent"`)
+
+	code, stdout, _ = runCommand("", "proc", "--procfs", root, "--rules", "../../testdata/rules/acme.yaml", "--env", "--output", "yaml", "201")
+	assert.Equal(t, 0, code)
+	assert.Contains(t, stdout, "rule: acme-agent")
+	assert.Contains(t, stdout, "/etc/acme.yml")
+
+	code, _, _ = runCommand("", "scan", "--procfs", root, "--rules", "missing.yaml")
+	assert.Equal(t, 2, code)
+
+	code, _, _ = runCommand("", "scan", "--procfs", root, "--match", `runtime ==`)
+	assert.Equal(t, 2, code)
+
+	code, _, _ = runCommand("", "proc", "--procfs", root, "300")
+	assert.Equal(t, 1, code)
+}
+
+func TestDiff(t *testing.T) {
+	code, stdout, _ := runCommand("", "diff", "java -Xmx1g -Da=1 -jar app.jar", "java -Da=2 -Xmx1024m -jar app.jar")
+	assert.Equal(t, 1, code)
+	assert.Equal(t, "property a changed: \"1\" -> \"2\"\n", stdout)
+
+	code, stdout, _ = runCommand("", "diff", "--output", "json", "python3 app.py", "python3 -u app.py")
+	assert.Equal(t, 1, code)
+	var change cmdline.Change
+	if err := json.Unmarshal([]byte(stdout), &change); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, cmdline.Change{Kind: cmdline.ChangeOption, Action: cmdline.ActionAdded, Name: "-u", New: "-u"}, change)
+
+	code, stdout, _ = runCommand("", "diff", "--output", "yaml", "java -cp a.jar Main", "java -classpath a.jar Main")
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "", stdout)
+
+	code, _, _ = runCommand("", "diff", "java Main")
+	assert.Equal(t, 2, code)
+	code, _, _ = runCommand("", "diff", "--output", "tokens", "java Main", "java Main")
+	assert.Equal(t, 2, code)
+}
+
+func TestUsage(t *testing.T) {
+	code, _, _ := runCommand("")
+	assert.Equal(t, 2, code)
+	code, _, _ = runCommand("", "unknown")
+	assert.Equal(t, 2, code)
+	code, _, _ = runCommand("", "parse", "--output", "xml", "java")
+	assert.Equal(t, 2, code)
+	code, _, _ = runCommand("", "proc", "abc")
+	assert.Equal(t, 2, code)
+}
@@ -0,0 +1,469 @@
+package cmdline
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// What a Change is about
+const (
+	ChangeExecutable = "executable"
+	ChangeRuntime    = "runtime"
+	// ChangeTarget is the main class, script, module, wrapped command or
+	// windows service, Name is its matcher field like "java.main"
+	ChangeTarget = "target"
+	// ChangeProperty is a java system property, Name is the property
+	ChangeProperty = "property"
+	// ChangeHeap is a heap size option of java, like -Xmx
+	ChangeHeap = "heap"
+	// ChangeOption is another option of the runtime or wrapper
+	ChangeOption = "option"
+	// ChangeArgument is an argument of the program
+	ChangeArgument   = "argument"
+	ChangeEnv        = "env"
+	ChangeWorkingDir = "workdir"
+)
+
+// What happened to the value of a Change
+const (
+	ActionAdded   = "added"
+	ActionRemoved = "removed"
+	ActionChanged = "changed"
+)
+
+// Change is a difference between two command lines, see Diff
+type Change struct {
+	Kind   string `json:"kind" yaml:"kind"`
+	Action string `json:"action" yaml:"action"`
+	// Name is the property, option or variable, empty for the kinds with a
+	// single value like ChangeExecutable
+	Name string `json:"name,omitempty" yaml:"name,omitempty"`
+	// Old and New are the values, an option is as written on the command
+	// line, like "-XX:+UseG1GC"
+	Old string `json:"old,omitempty" yaml:"old,omitempty"`
+	New string `json:"new,omitempty" yaml:"new,omitempty"`
+	// Depth is how many wrappers deep the change is, like 1 for the java
+	// of "sudo -u kafka java ..."
+	Depth int `json:"depth,omitempty" yaml:"depth,omitempty"`
+}
+
+// String describes the change, like `heap -Xmx changed: "-Xmx1g" -> "-Xmx2g"`
+func (c Change) String() string {
+	s := c.Kind
+	if c.Name != "" {
+		s += " " + c.Name
+	}
+	if c.Depth > 0 {
+		s = "depth " + strconv.Itoa(c.Depth) + ": " + s
+	}
+	switch c.Action {
+	case ActionAdded:
+		return s + " added: " + strconv.Quote(c.New)
+	case ActionRemoved:
+		return s + " removed: " + strconv.Quote(c.Old)
+	}
+	return s + " changed: " + strconv.Quote(c.Old) + " -> " + strconv.Quote(c.New)
+}
+
+// Diff compares what a and b run rather than their arguments, like the same
+// service started on two hosts. The options of the runtimes and wrappers
+// are compared by name, so reordered options are equal, -cp is -classpath
+// and the heap sizes 1g and 1024m are the same. The arguments of the
+// program keep their order. The wrapped commands are compared as command
+// lines too, at Depth 1 and more.
+//
+// The changes come in the order of the kinds above, by name within a kind.
+// Diff returns nil when a and b run the same.
+func Diff(a, b *CommandLine) []Change {
+	return diff(nil, a, b, 0)
+}
+
+func diff(changes []Change, a, b *CommandLine, depth int) []Change {
+	d := &differ{changes: changes, depth: depth}
+	d.value(ChangeExecutable, "", a.ExecutePath, b.ExecutePath)
+	d.runtime(a.Runtime, b.Runtime)
+
+	// the wrapped commands are compared as a whole, with their executables
+	// and arguments
+	aSub, bSub := diffSub(a), diffSub(b)
+	wrapped := aSub != nil && bSub != nil
+	if !wrapped {
+		aField, aTarget := diffTarget(a)
+		bField, bTarget := diffTarget(b)
+		if bField == "" {
+			bField = aField
+		}
+		d.value(ChangeTarget, bField, aTarget, bTarget)
+	}
+
+	aOptions, aArgs := diffArgs(a)
+	bOptions, bArgs := diffArgs(b)
+	d.options(aOptions, bOptions)
+	if wrapped {
+		d.changes = diff(d.changes, aSub, bSub, depth+1)
+		aArgs, bArgs = nil, nil
+	}
+	aOptions, aPositionals := programOptions(aArgs)
+	bOptions, bPositionals := programOptions(bArgs)
+	d.options(aOptions, bOptions)
+	d.value(ChangeArgument, "", strings.Join(aPositionals, " "), strings.Join(bPositionals, " "))
+
+	d.options(envOptions(a.Env), envOptions(b.Env))
+	d.value(ChangeWorkingDir, "", a.WorkingDir, b.WorkingDir)
+	if depth == 0 {
+		sortChanges(d.changes)
+	}
+	return d.changes
+}
+
+type differ struct {
+	changes []Change
+	depth   int
+}
+
+// value compares a value that may be missing, empty is missing
+func (d *differ) value(kind, name, a, b string) {
+	switch {
+	case a == b:
+	case a == "":
+		d.changes = append(d.changes, Change{Kind: kind, Action: ActionAdded, Name: name, New: b, Depth: d.depth})
+	case b == "":
+		d.changes = append(d.changes, Change{Kind: kind, Action: ActionRemoved, Name: name, Old: a, Depth: d.depth})
+	default:
+		d.changes = append(d.changes, Change{Kind: kind, Action: ActionChanged, Name: name, Old: a, New: b, Depth: d.depth})
+	}
+}
+
+func (d *differ) runtime(a, b *Runtime) {
+	var empty Runtime
+	if a == nil {
+		a = &empty
+	}
+	if b == nil {
+		b = &empty
+	}
+	d.value(ChangeRuntime, "", a.Name, b.Name)
+	if a.Name == b.Name {
+		d.value(ChangeRuntime, "version", a.Version, b.Version)
+		d.value(ChangeRuntime, "manager", a.Manager, b.Manager)
+	}
+}
+
+// options compares the options by kind and key. An option found once on
+// both sides is changed, the others are added or removed one by one.
+func (d *differ) options(a, b []diffOption) {
+	type key struct{ kind, name string }
+	byKey := func(options []diffOption) map[key][]diffOption {
+		m := map[key][]diffOption{}
+		for _, o := range options {
+			k := key{o.kind, o.key}
+			if o.single {
+				// the last one wins
+				m[k] = []diffOption{o}
+				continue
+			}
+			m[k] = append(m[k], o)
+		}
+		return m
+	}
+	aByKey, bByKey := byKey(a), byKey(b)
+
+	keys := make([]key, 0, len(aByKey)+len(bByKey))
+	for k := range aByKey {
+		keys = append(keys, k)
+	}
+	for k := range bByKey {
+		if _, ok := aByKey[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+
+	for _, k := range keys {
+		as, bs := aByKey[k], bByKey[k]
+		if len(as) == 1 && len(bs) == 1 {
+			if as[0].value != bs[0].value {
+				d.changes = append(d.changes, Change{Kind: k.kind, Action: ActionChanged, Name: k.name, Old: as[0].text, New: bs[0].text, Depth: d.depth})
+			}
+			continue
+		}
+
+		// the options that repeat, like --add-opens, are sets
+		count := map[string]int{}
+		for _, o := range bs {
+			count[o.value]++
+		}
+		for _, o := range as {
+			if count[o.value] > 0 {
+				count[o.value]--
+				continue
+			}
+			d.changes = append(d.changes, Change{Kind: k.kind, Action: ActionRemoved, Name: k.name, Old: o.text, Depth: d.depth})
+		}
+		count = map[string]int{}
+		for _, o := range as {
+			count[o.value]++
+		}
+		for _, o := range bs {
+			if count[o.value] > 0 {
+				count[o.value]--
+				continue
+			}
+			d.changes = append(d.changes, Change{Kind: k.kind, Action: ActionAdded, Name: k.name, New: o.text, Depth: d.depth})
+		}
+	}
+}
+
+// changeOrder is the order of the kinds in the result of Diff
+var changeOrder = map[string]int{
+	ChangeExecutable: 0,
+	ChangeRuntime:    1,
+	ChangeTarget:     2,
+	ChangeProperty:   3,
+	ChangeHeap:       4,
+	ChangeOption:     5,
+	ChangeArgument:   6,
+	ChangeEnv:        7,
+	ChangeWorkingDir: 8,
+}
+
+func sortChanges(changes []Change) {
+	sort.SliceStable(changes, func(i, j int) bool {
+		a, b := changes[i], changes[j]
+		if a.Depth != b.Depth {
+			return a.Depth < b.Depth
+		}
+		if a.Kind != b.Kind {
+			return changeOrder[a.Kind] < changeOrder[b.Kind]
+		}
+		return a.Name < b.Name
+	})
+}
+
+// diffOption is an option compared by Diff
+type diffOption struct {
+	kind string
+	// key tells the options apart, like "-Xmx" or "-XX:UseG1GC"
+	key string
+	// value is compared, text is shown
+	value  string
+	text   string
+	single bool
+}
+
+// diffTarget is what c runs, with the matcher field that names it
+func diffTarget(c *CommandLine) (string, string) {
+	switch {
+	case c.Java != nil:
+		return "java.main", c.Java.ClassName
+	case c.Python != nil:
+		if c.runtimeTerminal() != nil {
+			return "python.module", c.Python.FilePath
+		}
+		return "python.script", c.Python.FilePath
+	case c.Ruby != nil:
+		return "ruby.script", c.Ruby.FilePath
+	case c.Sub != nil:
+		return "sub.command", c.Sub.Command
+	case c.Windows != nil:
+		return "windows.service", windowsServiceName(c.Windows)
+	case c.Custom != nil:
+		return "custom.rule", c.Custom.Rule
+	}
+	return "", ""
+}
+
+// diffSub is the wrapped command of c, parsed when the parser didn't
+func diffSub(c *CommandLine) *CommandLine {
+	if c.Sub == nil {
+		return nil
+	}
+	if c.Sub.CommandLine != nil {
+		return c.Sub.CommandLine
+	}
+	sub, _ := Parse(c.isWindows(), c.Sub.Command, c.Sub.Args)
+	return sub
+}
+
+// diffArgs splits the arguments of c into the options of its runtime or
+// wrapper and the arguments of the program
+func diffArgs(c *CommandLine) ([]diffOption, []string) {
+	start := c.programArgsStart()
+	var grammar *OptionGrammar
+	switch c.runtime() {
+	case "java":
+		grammar = &javaGrammar
+	case "python":
+		grammar = &pythonGrammar
+	case "ruby":
+		grammar = &rubyGrammar
+	default:
+		if c.Sub == nil {
+			return nil, c.Args
+		}
+		grammar = &wrapperGrammar
+		if strings.EqualFold(executableName(c.isWindows(), c.ExecutePath), "sudo") {
+			grammar = &sudoGrammar
+		}
+	}
+
+	parsed := grammar.ParseOptions(c.Args[:start])
+	options := make([]diffOption, 0, len(parsed.Options))
+	for _, o := range parsed.Options {
+		if o.Spec != nil && o.Spec.Terminal {
+			// the target
+			continue
+		}
+		if grammar == &javaGrammar && o.Spec != nil && o.Spec.Prefix {
+			if java, ok := javaOption(o); ok {
+				options = append(options, java)
+				continue
+			}
+		}
+		options = append(options, diffOption{kind: ChangeOption, key: optionKey(o), value: o.Value, text: optionText(o)})
+	}
+	return options, c.Args[start:]
+}
+
+// optionKey is the first name of a known option, so that -cp is
+// -classpath
+func optionKey(o ParsedOption) string {
+	if o.Spec != nil {
+		return o.Spec.Names[0]
+	}
+	return o.Name
+}
+
+func optionText(o ParsedOption) string {
+	switch {
+	case o.Spec != nil && o.Spec.Prefix:
+		return o.Name + o.Value
+	case o.ValueIndex != o.Index:
+		return o.Name + " " + o.Value
+	case o.HasValue:
+		return o.Name + "=" + o.Value
+	}
+	return o.Name
+}
+
+// heapOptions are the heap options of java by their spellings
+var heapOptions = map[string]string{
+	"-Xmx":                     "-Xmx",
+	"-XX:MaxHeapSize":          "-Xmx",
+	"-Xms":                     "-Xms",
+	"-XX:InitialHeapSize":      "-Xms",
+	"-Xmn":                     "-Xmn",
+	"-XX:MaxRAMPercentage":     "-XX:MaxRAMPercentage",
+	"-XX:InitialRAMPercentage": "-XX:InitialRAMPercentage",
+	"-XX:MinRAMPercentage":     "-XX:MinRAMPercentage",
+}
+
+// javaOption reads the -D, -X and -XX options of java, the last one of a
+// name wins like in the JVM
+func javaOption(o ParsedOption) (diffOption, bool) {
+	text := optionText(o)
+	switch o.Spec.Names[0] {
+	case "-D":
+		name, value, _ := strings.Cut(o.Value, "=")
+		return diffOption{kind: ChangeProperty, key: name, value: value, text: value, single: true}, true
+	case "-X":
+	default:
+		return diffOption{}, false
+	}
+
+	var key, value string
+	if rest, ok := strings.CutPrefix(o.Value, "X:"); ok {
+		if rest != "" && (rest[0] == '+' || rest[0] == '-') {
+			key, value = "-XX:"+rest[1:], rest[:1]
+		} else {
+			name, v, _ := strings.Cut(rest, "=")
+			key, value = "-XX:"+name, v
+		}
+	} else {
+		// -Xmx1g, -Xss512k, -Xlog:gc, -Xint
+		end := strings.IndexFunc(o.Value, func(r rune) bool {
+			return r == ':' || r >= '0' && r <= '9'
+		})
+		if end < 0 {
+			end = len(o.Value)
+		}
+		key, value = "-X"+o.Value[:end], strings.TrimPrefix(o.Value[end:], ":")
+	}
+
+	heap, ok := heapOptions[key]
+	if !ok {
+		// -Xlog repeats for every output
+		return diffOption{kind: ChangeOption, key: key, value: value, text: text, single: key != "-Xlog"}, true
+	}
+	if size, ok := parseJavaSize(value); ok {
+		value = strconv.FormatUint(size, 10)
+	} else if f, err := strconv.ParseFloat(value, 64); err == nil {
+		value = strconv.FormatFloat(f, 'g', -1, 64)
+	}
+	return diffOption{kind: ChangeHeap, key: heap, value: value, text: text, single: true}, true
+}
+
+// parseJavaSize reads the sizes of java like 512m or 2G in bytes
+func parseJavaSize(s string) (uint64, bool) {
+	shift := 0
+	if s != "" {
+		switch s[len(s)-1] {
+		case 'k', 'K':
+			shift = 10
+		case 'm', 'M':
+			shift = 20
+		case 'g', 'G':
+			shift = 30
+		case 't', 'T':
+			shift = 40
+		}
+	}
+	if shift > 0 {
+		s = s[:len(s)-1]
+	}
+	n, err := strconv.ParseUint(s, 10, 64)
+	if err != nil || n > 1<<(64-shift)-1 {
+		return 0, false
+	}
+	return n << shift, true
+}
+
+// programOptions splits the arguments of a program into its options and
+// positionals. Without the grammar of the program, an option without '='
+// takes the next argument that isn't an option as its value.
+func programOptions(args []string) ([]diffOption, []string) {
+	var (
+		options     []diffOption
+		positionals []string
+	)
+	for idx := 0; idx < len(args); idx++ {
+		a := args[idx]
+		if a == "--" {
+			positionals = append(positionals, args[idx:]...)
+			break
+		}
+		if len(a) < 2 || a[0] != '-' {
+			positionals = append(positionals, a)
+			continue
+		}
+
+		name, value, hasValue := strings.Cut(a, "=")
+		text := a
+		if !hasValue && idx+1 < len(args) && !strings.HasPrefix(args[idx+1], "-") {
+			idx++
+			value, text = args[idx], a+" "+args[idx]
+		}
+		options = append(options, diffOption{kind: ChangeArgument, key: name, value: value, text: text})
+	}
+	return options, positionals
+}
+
+// envOptions are the assignments of env by name, the last one wins
+func envOptions(env []string) []diffOption {
+	options := make([]diffOption, 0, len(env))
+	for _, e := range env {
+		name, value, _ := strings.Cut(e, "=")
+		options = append(options, diffOption{kind: ChangeEnv, key: name, value: value, text: value, single: true})
+	}
+	return options
+}
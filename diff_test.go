@@ -0,0 +1,157 @@
+package cmdline
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected []Change
+	}{
+		{
+			a: "java -Xmx1g -Dkafka.logs.dir=/var/log/kafka -cp /opt/kafka/libs/* -XX:+UseG1GC kafka.Kafka server.properties",
+			b: "java -XX:+UseG1GC -classpath /opt/kafka/libs/* -Dkafka.logs.dir=/var/log/kafka -XX:MaxHeapSize=1024m kafka.Kafka server.properties",
+		},
+		{
+			a: "java -Xmx1g -Xms512m -Da=1 -Db=2 -XX:+UseG1GC -XX:MaxGCPauseMillis=200 -jar /opt/app.jar",
+			b: "java -Xmx2g -Xms512m -Da=1 -Db=3 -Dc -XX:-UseG1GC -XX:MaxGCPauseMillis=200 -jar /opt/app-2.jar",
+			expected: []Change{
+				{Kind: ChangeTarget, Action: ActionChanged, Name: "java.main", Old: "/opt/app.jar", New: "/opt/app-2.jar"},
+				{Kind: ChangeProperty, Action: ActionChanged, Name: "b", Old: "2", New: "3"},
+				{Kind: ChangeProperty, Action: ActionAdded, Name: "c"},
+				{Kind: ChangeHeap, Action: ActionChanged, Name: "-Xmx", Old: "-Xmx1g", New: "-Xmx2g"},
+				{Kind: ChangeOption, Action: ActionChanged, Name: "-XX:UseG1GC", Old: "-XX:+UseG1GC", New: "-XX:-UseG1GC"},
+			},
+		},
+		{
+			// the last value wins, like in the JVM
+			a: "java -Xmx1g -Xmx2g -Dp=1 -Dp=2 Main",
+			b: "java -Xmx2g -Dp=2 Main",
+		},
+		{
+			a: "java --add-opens java.base/java.lang=ALL-UNNAMED --add-opens java.base/java.io=ALL-UNNAMED Main",
+			b: "java --add-opens java.base/java.io=ALL-UNNAMED --add-opens java.base/java.nio=ALL-UNNAMED Main",
+			expected: []Change{
+				{Kind: ChangeOption, Action: ActionRemoved, Name: "--add-opens", Old: "--add-opens java.base/java.lang=ALL-UNNAMED"},
+				{Kind: ChangeOption, Action: ActionAdded, Name: "--add-opens", New: "--add-opens java.base/java.nio=ALL-UNNAMED"},
+			},
+		},
+		{
+			a: "/usr/lib/jvm/java-11/bin/java org.example.Main --port 80 run",
+			b: "/usr/lib/jvm/java-17/bin/java org.example.Other run --port=8080 --debug",
+			expected: []Change{
+				{Kind: ChangeExecutable, Action: ActionChanged, Old: "/usr/lib/jvm/java-11/bin/java", New: "/usr/lib/jvm/java-17/bin/java"},
+				{Kind: ChangeRuntime, Action: ActionChanged, Name: "version", Old: "11", New: "17"},
+				{Kind: ChangeTarget, Action: ActionChanged, Name: "java.main", Old: "org.example.Main", New: "org.example.Other"},
+				{Kind: ChangeArgument, Action: ActionAdded, Name: "--debug", New: "--debug"},
+				{Kind: ChangeArgument, Action: ActionChanged, Name: "--port", Old: "--port 80", New: "--port=8080"},
+			},
+		},
+		{
+			a: "LANG=C python3 -u -m http.server 8000",
+			b: "LANG=en_US.UTF-8 TZ=UTC python3 -m http.server 8001",
+			expected: []Change{
+				{Kind: ChangeOption, Action: ActionRemoved, Name: "-u", Old: "-u"},
+				{Kind: ChangeArgument, Action: ActionChanged, Old: "8000", New: "8001"},
+				{Kind: ChangeEnv, Action: ActionChanged, Name: "LANG", Old: "C", New: "en_US.UTF-8"},
+				{Kind: ChangeEnv, Action: ActionAdded, Name: "TZ", New: "UTC"},
+			},
+		},
+		{
+			a: "python3 app.py",
+			b: "java -jar app.jar",
+			expected: []Change{
+				{Kind: ChangeExecutable, Action: ActionChanged, Old: "python3", New: "java"},
+				{Kind: ChangeRuntime, Action: ActionChanged, Old: "python", New: "java"},
+				{Kind: ChangeTarget, Action: ActionChanged, Name: "java.main", Old: "app.py", New: "app.jar"},
+			},
+		},
+		{
+			// the wrapped commands are compared too, parsed or not
+			a: "sudo -u kafka java -Xmx1g kafka.Kafka",
+			b: "sudo --user=kafka -E java -Xmx4g kafka.Kafka",
+			expected: []Change{
+				{Kind: ChangeOption, Action: ActionAdded, Name: "-E", New: "-E"},
+				{Kind: ChangeHeap, Action: ActionChanged, Name: "-Xmx", Old: "-Xmx1g", New: "-Xmx4g", Depth: 1},
+			},
+		},
+		{
+			a: "nginx -g 'daemon off;'",
+			b: "nginx -g 'daemon off;'",
+		},
+	}
+
+	for _, tt := range tests {
+		a, err := ParseCommandLine(false, tt.a)
+		assert.NoError(t, err, tt.a)
+		b, err := ParseCommandLine(false, tt.b)
+		assert.NoError(t, err, tt.b)
+		assert.Equal(t, tt.expected, Diff(a, b), tt.a+" | "+tt.b)
+	}
+
+	// the wrapped commands of a parser with WithMaxDepth are taken as they are
+	p := NewParser(WithMaxDepth(1))
+	a, _ := p.ParseCommandLine("sudo python3 app.py")
+	b, _ := p.ParseCommandLine("sudo python3 app2.py")
+	assert.Equal(t, []Change{
+		{Kind: ChangeTarget, Action: ActionChanged, Name: "python.script", Old: "app.py", New: "app2.py", Depth: 1},
+	}, Diff(a, b))
+
+	a.WorkingDir, b.WorkingDir = "/srv/a", "/srv/b"
+	assert.Contains(t, Diff(a, b), Change{Kind: ChangeWorkingDir, Action: ActionChanged, Old: "/srv/a", New: "/srv/b"})
+}
+
+func TestChange(t *testing.T) {
+	tests := []struct {
+		change   Change
+		expected string
+		json     string
+	}{
+		{
+			change:   Change{Kind: ChangeHeap, Action: ActionChanged, Name: "-Xmx", Old: "-Xmx1g", New: "-Xmx2g"},
+			expected: `heap -Xmx changed: "-Xmx1g" -> "-Xmx2g"`,
+			json:     `{"kind":"heap","action":"changed","name":"-Xmx","old":"-Xmx1g","new":"-Xmx2g"}`,
+		},
+		{
+			change:   Change{Kind: ChangeProperty, Action: ActionAdded, Name: "c", Depth: 1},
+			expected: `depth 1: property c added: ""`,
+			json:     `{"kind":"property","action":"added","name":"c","depth":1}`,
+		},
+		{
+			change:   Change{Kind: ChangeExecutable, Action: ActionRemoved, Old: "java"},
+			expected: `executable removed: "java"`,
+			json:     `{"kind":"executable","action":"removed","old":"java"}`,
+		},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, tt.change.String())
+		data, err := json.Marshal(tt.change)
+		assert.NoError(t, err)
+		assert.Equal(t, tt.json, string(data))
+	}
+}
+
+func TestParseJavaSize(t *testing.T) {
+	tests := []struct {
+		s        string
+		expected uint64
+		ok       bool
+	}{
+		{"1g", 1 << 30, true},
+		{"1024M", 1 << 30, true},
+		{"512k", 512 << 10, true},
+		{"1073741824", 1 << 30, true},
+		{"1.5g", 0, false},
+		{"", 0, false},
+		{"99999999999t", 0, false},
+	}
+	for _, tt := range tests {
+		size, ok := parseJavaSize(tt.s)
+		assert.Equal(t, tt.ok, ok, tt.s)
+		assert.Equal(t, tt.expected, size, tt.s)
+	}
+}
@@ -0,0 +1,204 @@
+package cmdline
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+)
+
+// Options of the runtimes that take their value in the next argument, these
+// are removed together with their value by RemoveOption
+var runtimeOptionsWithValue = map[string][]string{
+	"java": {
+		"-cp", "-classpath", "--class-path", "-p", "--module-path", "--upgrade-module-path",
+		"--add-modules", "--limit-modules", "--add-opens", "--add-exports", "--add-reads", "--patch-module",
+	},
+	"python": {"-W", "-X"},
+	"ruby":   {"-r", "-I", "-C"},
+	"node":   {"-r", "--require", "--import", "--loader", "--experimental-loader"},
+}
+
+// InsertRuntimeOption inserts options for the runtime itself, e.g.
+// "-javaagent:/opt/apm.jar" for java or "-r", "dd-trace" for node, in front
+// of the main class, script or module, so the program doesn't see them.
+//
+// Like every edit the command line is parsed again by the default parser of
+// its flavour, the returned error is the one Parse returns for the new
+// arguments.
+func (c *CommandLine) InsertRuntimeOption(opts ...string) error {
+	end := c.runtimeOptionsEnd()
+
+	args := make([]string, 0, len(c.Args)+len(opts))
+	args = append(args, c.Args[:end]...)
+	args = append(args, opts...)
+	args = append(args, c.Args[end:]...)
+	return c.reparse(c.ExecutePath, args)
+}
+
+// SetSystemProperty sets "-Dname=value" on a java command line, replacing
+// the existing values of the property.
+func (c *CommandLine) SetSystemProperty(name, value string) error {
+	if c.runtime() != "java" {
+		return errors.New("system property needs a java command line - `" + c.ExecutePath + "`")
+	}
+
+	prop := "-D" + name + "=" + value
+	end := c.runtimeOptionsEnd()
+
+	args := make([]string, 0, len(c.Args)+1)
+	found := false
+	for idx, a := range c.Args {
+		if idx < end && (a == "-D"+name || strings.HasPrefix(a, "-D"+name+"=")) {
+			if found {
+				continue
+			}
+			found = true
+			a = prop
+		}
+		args = append(args, a)
+	}
+	if !found {
+		args = append(args, "")
+		copy(args[end+1:], args[end:])
+		args[end] = prop
+	}
+	return c.reparse(c.ExecutePath, args)
+}
+
+// RemoveOption removes the runtime options called name, both the bare
+// option and the "name=value" or "name:value" forms, e.g.
+// RemoveOption("-javaagent") or RemoveOption("-Dfoo"). Options known to take
+// their value in the next argument, like "-cp", lose that argument too.
+// Program arguments are never touched.
+func (c *CommandLine) RemoveOption(name string) (bool, error) {
+	end := c.runtimeOptionsEnd()
+	withValue := false
+	for _, opt := range runtimeOptionsWithValue[c.runtime()] {
+		if opt == name {
+			withValue = true
+			break
+		}
+	}
+
+	args := make([]string, 0, len(c.Args))
+	removed := false
+	for idx := 0; idx < len(c.Args); idx++ {
+		a := c.Args[idx]
+		if idx < end && (a == name || strings.HasPrefix(a, name+"=") || strings.HasPrefix(a, name+":")) {
+			removed = true
+			if withValue && a == name && idx+1 < end {
+				idx++
+			}
+			continue
+		}
+		args = append(args, a)
+	}
+	if !removed {
+		return false, nil
+	}
+	return true, c.reparse(c.ExecutePath, args)
+}
+
+// WrapWith runs the command line through a wrapper, e.g. WrapWith("ddtrace-run")
+// turns "python app.py" into "ddtrace-run python app.py".
+func (c *CommandLine) WrapWith(wrapper ...string) error {
+	if len(wrapper) == 0 {
+		return nil
+	}
+
+	args := make([]string, 0, len(wrapper)+len(c.Args))
+	args = append(args, wrapper[1:]...)
+	args = append(args, c.Argv()...)
+	return c.reparse(wrapper[0], args)
+}
+
+// reparse parses exe and args with the default parser of the flavour of c.
+// The environment, the working directory, the resolved executable and the
+// script of a "#!" line are kept, the indexes of the expansions are stale
+// after an edit.
+func (c *CommandLine) reparse(exe string, args []string) error {
+	isWindows := c.isWindows()
+	parsed, err := Parse(isWindows, exe, args)
+	parsed.Env, parsed.WorkingDir = c.Env, c.WorkingDir
+	if c.Resolved != nil && exe == c.ExecutePath {
+		parsed.Resolved = c.Resolved
+		parsed.Runtime = detectRuntime(isWindows, parsed)
+	}
+	if c.ViaShebang {
+		if exe == c.ExecutePath {
+			// the edits are in front of the script
+			parsed.ViaShebang = true
+			parsed.ScriptIndex = c.ScriptIndex + len(args) - len(c.Args)
+		} else if parsed.Sub != nil && parsed.Sub.CommandLine != nil {
+			parsed.Sub.CommandLine.ViaShebang = true
+			parsed.Sub.CommandLine.ScriptIndex = c.ScriptIndex
+		}
+	}
+	*c = *parsed
+	return err
+}
+
+// the edits keep the windows flavour of the command line they started from
+func (c *CommandLine) isWindows() bool {
+	return c.Windows != nil ||
+		strings.ContainsRune(c.ExecutePath, '\\') ||
+		strings.EqualFold(filepath.Ext(c.ExecutePath), ".exe")
+}
+
+func (c *CommandLine) runtime() string {
+	switch {
+	case c.Java != nil:
+		return "java"
+	case c.Python != nil:
+		return "python"
+	case c.Ruby != nil:
+		return "ruby"
+	}
+
+	exe, _ := splitVersion(strings.ToLower(executableName(c.isWindows(), c.ExecutePath)))
+	switch exe {
+	case "java", "python", "ruby", "node":
+		return exe
+	}
+	return ""
+}
+
+// runtimeOptionsEnd returns the index in Args of the main class, script or
+// module, including the flag that introduces it, or 0 if there is none
+func (c *CommandLine) runtimeOptionsEnd() int {
+	if terminal := c.runtimeTerminal(); terminal != nil {
+		return terminal.Index
+	}
+
+	var rest []string
+	switch {
+	case c.Java != nil:
+		rest = c.Java.Args
+	case c.Python != nil:
+		rest = c.Python.Args
+	case c.Ruby != nil:
+		rest = c.Ruby.Args
+	case c.Sub != nil:
+		rest = c.Sub.Args
+	default:
+		return 0
+	}
+
+	end := len(c.Args) - len(rest) - 1
+	if end < 0 {
+		return 0
+	}
+	return end
+}
+
+// runtimeTerminal returns the option that names the program of the runtime,
+// like java -jar or python -m, or nil
+func (c *CommandLine) runtimeTerminal() *ParsedOption {
+	switch {
+	case c.Java != nil:
+		return javaGrammar.ParseOptions(c.Args).Terminal()
+	case c.Python != nil:
+		return pythonGrammar.ParseOptions(c.Args).Terminal()
+	}
+	return nil
+}
@@ -0,0 +1,171 @@
+package cmdline
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEdit(t *testing.T) {
+	tests := []struct {
+		isWindows bool
+		name      string
+		cmdline   string
+		edit      func(c *CommandLine) error
+		expected  []string
+	}{
+		{
+			name:    "java agent before the main class",
+			cmdline: "java -Xmx4000m -cp /opt/lib com.datadog.example.HelloWorld -port 80",
+			edit: func(c *CommandLine) error {
+				return c.InsertRuntimeOption("-javaagent:/opt/apm.jar")
+			},
+			expected: []string{"java", "-Xmx4000m", "-cp", "/opt/lib", "-javaagent:/opt/apm.jar", "com.datadog.example.HelloWorld", "-port", "80"},
+		},
+		{
+			name:    "java agent before -jar",
+			cmdline: "java -Xmx4000m -jar /opt/sheepdog/bin/myservice.jar run",
+			edit: func(c *CommandLine) error {
+				return c.InsertRuntimeOption("-javaagent:/opt/apm.jar")
+			},
+			expected: []string{"java", "-Xmx4000m", "-javaagent:/opt/apm.jar", "-jar", "/opt/sheepdog/bin/myservice.jar", "run"},
+		},
+		{
+			name:    "node require",
+			cmdline: "/usr/bin/node --max-old-space-size=512 server.js --port 3000",
+			edit: func(c *CommandLine) error {
+				return c.InsertRuntimeOption("-r", "dd-trace/init")
+			},
+			expected: []string{"/usr/bin/node", "-r", "dd-trace/init", "--max-old-space-size=512", "server.js", "--port", "3000"},
+		},
+		{
+			name:    "python option before module",
+			cmdline: "python3 -u -m flask run",
+			edit: func(c *CommandLine) error {
+				return c.InsertRuntimeOption("-X", "importtime")
+			},
+			expected: []string{"python3", "-u", "-X", "importtime", "-m", "flask", "run"},
+		},
+		{
+			name:    "set new system property",
+			cmdline: "java -Xmx4000m kafka.Kafka config/server.properties",
+			edit: func(c *CommandLine) error {
+				return c.SetSystemProperty("dd.service", "kafka")
+			},
+			expected: []string{"java", "-Xmx4000m", "-Ddd.service=kafka", "kafka.Kafka", "config/server.properties"},
+		},
+		{
+			name:    "replace system property",
+			cmdline: "java -Ddd.env=dev -Xmx4000m -Ddd.env=test kafka.Kafka -Ddd.env=program",
+			edit: func(c *CommandLine) error {
+				return c.SetSystemProperty("dd.env", "prod")
+			},
+			expected: []string{"java", "-Ddd.env=prod", "-Xmx4000m", "kafka.Kafka", "-Ddd.env=program"},
+		},
+		{
+			name:    "remove agent",
+			cmdline: "java -javaagent:/opt/apm.jar -Xmx4000m kafka.Kafka -javaagent:x",
+			edit: func(c *CommandLine) error {
+				_, err := c.RemoveOption("-javaagent")
+				return err
+			},
+			expected: []string{"java", "-Xmx4000m", "kafka.Kafka", "-javaagent:x"},
+		},
+		{
+			name:    "remove option with value",
+			cmdline: "java -cp /opt/lib -Dfoo=1 kafka.Kafka",
+			edit: func(c *CommandLine) error {
+				if _, err := c.RemoveOption("-cp"); err != nil {
+					return err
+				}
+				_, err := c.RemoveOption("-Dfoo")
+				return err
+			},
+			expected: []string{"java", "kafka.Kafka"},
+		},
+		{
+			name:    "wrap python",
+			cmdline: "python3 app.py --debug",
+			edit: func(c *CommandLine) error {
+				return c.WrapWith("ddtrace-run")
+			},
+			expected: []string{"ddtrace-run", "python3", "app.py", "--debug"},
+		},
+		{
+			isWindows: true,
+			name:      "windows java agent",
+			cmdline:   `C:\jdk\bin\java.exe -Xmx1g com.tpt.nm.Server`,
+			edit: func(c *CommandLine) error {
+				return c.InsertRuntimeOption(`-javaagent:C:\apm\apm.jar`)
+			},
+			expected: []string{`C:\jdk\bin\java.exe`, "-Xmx1g", `-javaagent:C:\apm\apm.jar`, "com.tpt.nm.Server"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := ParseCommandLine(tt.isWindows, tt.cmdline)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+
+			if err := tt.edit(c); err != nil {
+				t.Error(err)
+				return
+			}
+			assert.Equal(t, tt.expected, c.Argv())
+
+			// the runtime specific parts follow the edit
+			expected, _ := Parse(tt.isWindows, tt.expected[0], tt.expected[1:])
+			assert.Equal(t, expected, c)
+		})
+	}
+}
+
+func TestEditKeepsRuntimeArgs(t *testing.T) {
+	c, err := ParseCommandLine(false, "java -Xmx4000m kafka.Kafka config/server.properties")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if err := c.InsertRuntimeOption("-javaagent:/opt/apm.jar"); err != nil {
+		t.Error(err)
+		return
+	}
+	assert.Equal(t, "kafka.Kafka", c.Java.ClassName)
+	assert.Equal(t, []string{"config/server.properties"}, c.Java.Args)
+
+	if err := c.WrapWith("sudo", "-u", "kafka"); err != nil {
+		t.Error(err)
+		return
+	}
+	assert.Nil(t, c.Java)
+	assert.Equal(t, "java", c.Sub.Command)
+}
+
+func TestEditShebang(t *testing.T) {
+	// the links and the script of a "#!" line are kept
+	fsys := linkFS{fstest.MapFS{
+		"usr/bin/python3":    link("python3.11"),
+		"usr/bin/python3.11": &fstest.MapFile{},
+		"opt/app/server.py":  script("#!/usr/bin/python3"),
+	}}
+	c, err := NewParser(WithFS(fsys)).ParseCommandLine("/opt/app/server.py --debug")
+	assert.NoError(t, err)
+	assert.NoError(t, c.InsertRuntimeOption("-X", "dev"))
+	assert.Equal(t, []string{"-X", "dev", "/opt/app/server.py", "--debug"}, c.Args)
+	assert.True(t, c.ViaShebang)
+	assert.Equal(t, 2, c.ScriptIndex)
+	assert.Equal(t, &Resolution{Path: "/usr/bin/python3.11", Links: []string{"/usr/bin/python3.11"}}, c.Resolved)
+	assert.Equal(t, &Runtime{Name: "python", Version: "3.11"}, c.Runtime)
+}
+
+func TestSetSystemPropertyNotJava(t *testing.T) {
+	c, _ := ParseCommandLine(false, "python3 app.py")
+	if err := c.SetSystemProperty("a", "b"); err == nil {
+		t.Error("want error got ok")
+	}
+}
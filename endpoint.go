@@ -0,0 +1,460 @@
+package cmdline
+
+import (
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// What an endpoint is for, the purpose is empty when the flag doesn't tell
+const (
+	PurposeHTTP    = "http"
+	PurposeJMX     = "jmx"
+	PurposeDebug   = "debug"
+	PurposeMetrics = "metrics"
+)
+
+// Endpoint is an address a process is told to listen on
+type Endpoint struct {
+	// Host is empty when only the port is given
+	Host string
+	// Port is 0 for unix sockets
+	Port int
+	// Protocol is "tcp", "udp" or "unix"
+	Protocol string
+	// Path is the path of a unix socket
+	Path    string
+	Purpose string
+
+	// Index is the index in Args of the argument with the address, -1 for
+	// an Env assignment
+	Index int
+}
+
+type endpointValue int
+
+const (
+	// a port number
+	valuePort endpointValue = iota
+	// a port, host:port, url or unix socket
+	valueAddress
+	// a list of urls separated by ';' or ','
+	valueURLs
+	// the host of the port flags of the same purpose
+	valueHost
+	// the path of a unix socket
+	valueSocket
+	// the options of -agentlib:jdwp, with address=[host:]port
+	valueJDWP
+	// the jmx exporter agent, with =[host:]port:config
+	valueJMXExporter
+)
+
+type endpointRule struct {
+	// Flag is matched as "flag value" and "flag=value"
+	Flag string
+	// Prefix matches the arguments that start with Flag, the rest is the value
+	Prefix bool
+	// Attached flags take their value only with '='
+	Attached bool
+	// Default is the port of the flag without value, 0 if it needs one
+	Default  int
+	Value    endpointValue
+	Protocol string
+	Purpose  string
+}
+
+// the rules of any binary, after the more specific ones
+var genericEndpointRules = []endpointRule{
+	{Flag: "--port", Value: valuePort},
+	{Flag: "-p", Value: valuePort},
+	{Flag: "--host", Value: valueHost},
+	{Flag: "--bind", Value: valueAddress},
+	{Flag: "--listen", Value: valueAddress},
+	{Flag: "--listen-address", Value: valueAddress},
+	{Flag: "--bind-address", Value: valueHost},
+	{Flag: "--http-port", Value: valuePort, Purpose: PurposeHTTP},
+	{Flag: "--https-port", Value: valuePort, Purpose: PurposeHTTP},
+	{Flag: "--urls", Value: valueURLs, Purpose: PurposeHTTP},
+	{Flag: "--server.port", Value: valuePort, Purpose: PurposeHTTP},
+	{Flag: "--metrics-port", Value: valuePort, Purpose: PurposeMetrics},
+	{Flag: "--metrics-addr", Value: valueAddress, Purpose: PurposeMetrics},
+	{Flag: "--metrics-address", Value: valueAddress, Purpose: PurposeMetrics},
+	{Flag: "--web.listen-address", Value: valueAddress, Purpose: PurposeMetrics},
+	{Flag: "--socket", Value: valueSocket},
+	{Flag: "--unix-socket", Value: valueSocket},
+	{Flag: "--udp-port", Value: valuePort, Protocol: "udp"},
+}
+
+// the clients take the port of the server they connect to, like psql -p
+var endpointClients = []string{
+	"psql", "mysql", "mysqladmin", "mysqldump", "pg_dump", "pg_restore", "pg_isready",
+	"ssh", "scp", "sftp", "rsync", "redis-cli", "mongo", "mongosh", "mongodump",
+	"curl", "wget", "nc", "ncat", "telnet", "ftp", "clickhouse-client", "cqlsh",
+}
+
+// the rules of the runtimes, they see the options of the runtime and the
+// arguments of the program
+var runtimeEndpointRules = map[string][]endpointRule{
+	"java": {
+		{Flag: "-Dserver.port", Attached: true, Value: valuePort, Purpose: PurposeHTTP},
+		{Flag: "-Dserver.address", Attached: true, Value: valueHost, Purpose: PurposeHTTP},
+		{Flag: "-Dhttp.port", Attached: true, Value: valuePort, Purpose: PurposeHTTP},
+		{Flag: "-Dhttps.port", Attached: true, Value: valuePort, Purpose: PurposeHTTP},
+		{Flag: "-Djetty.http.port", Attached: true, Value: valuePort, Purpose: PurposeHTTP},
+		{Flag: "-Dmanagement.server.port", Attached: true, Value: valuePort, Purpose: PurposeMetrics},
+		{Flag: "-Dcom.sun.management.jmxremote.port", Attached: true, Value: valuePort, Purpose: PurposeJMX},
+		{Flag: "-Dcom.sun.management.jmxremote.rmi.port", Attached: true, Value: valuePort, Purpose: PurposeJMX},
+		{Flag: "-Djava.rmi.server.hostname", Attached: true, Value: valueHost, Purpose: PurposeJMX},
+		{Flag: "-agentlib:jdwp=", Prefix: true, Value: valueJDWP, Purpose: PurposeDebug},
+		{Flag: "-Xrunjdwp:", Prefix: true, Value: valueJDWP, Purpose: PurposeDebug},
+		{Flag: "-javaagent:", Prefix: true, Value: valueJMXExporter, Purpose: PurposeMetrics},
+	},
+	"node": {
+		{Flag: "--inspect", Attached: true, Default: 9229, Value: valueAddress, Purpose: PurposeDebug},
+		{Flag: "--inspect-brk", Attached: true, Default: 9229, Value: valueAddress, Purpose: PurposeDebug},
+		{Flag: "--inspect-port", Value: valueAddress, Purpose: PurposeDebug},
+	},
+}
+
+// the rules of the products, keyed by the executable, script, module or
+// wrapped command without version
+var productEndpointRules = map[string][]endpointRule{
+	"gunicorn": {
+		{Flag: "-b", Value: valueAddress, Purpose: PurposeHTTP},
+		{Flag: "--bind", Value: valueAddress, Purpose: PurposeHTTP},
+	},
+	"uvicorn": {
+		{Flag: "--host", Value: valueHost, Purpose: PurposeHTTP},
+		{Flag: "--port", Value: valuePort, Purpose: PurposeHTTP},
+		{Flag: "--uds", Value: valueSocket, Purpose: PurposeHTTP},
+	},
+	"hypercorn": {
+		{Flag: "-b", Value: valueAddress, Purpose: PurposeHTTP},
+		{Flag: "--bind", Value: valueAddress, Purpose: PurposeHTTP},
+	},
+	"flask": {
+		{Flag: "-h", Value: valueHost, Purpose: PurposeHTTP},
+		{Flag: "--host", Value: valueHost, Purpose: PurposeHTTP},
+		{Flag: "-p", Value: valuePort, Purpose: PurposeHTTP},
+		{Flag: "--port", Value: valuePort, Purpose: PurposeHTTP},
+	},
+	"manage": {
+		{Flag: "runserver", Default: 8000, Value: valueAddress, Purpose: PurposeHTTP},
+	},
+	"debugpy": {
+		{Flag: "--listen", Value: valueAddress, Purpose: PurposeDebug},
+	},
+	"rails": {
+		{Flag: "-b", Value: valueHost, Purpose: PurposeHTTP},
+		{Flag: "--binding", Value: valueHost, Purpose: PurposeHTTP},
+		{Flag: "-p", Value: valuePort, Purpose: PurposeHTTP},
+		{Flag: "--port", Value: valuePort, Purpose: PurposeHTTP},
+	},
+	"puma": {
+		{Flag: "-b", Value: valueAddress, Purpose: PurposeHTTP},
+		{Flag: "--bind", Value: valueAddress, Purpose: PurposeHTTP},
+		{Flag: "-p", Value: valuePort, Purpose: PurposeHTTP},
+		{Flag: "--port", Value: valuePort, Purpose: PurposeHTTP},
+	},
+	"rackup": {
+		{Flag: "-o", Value: valueHost, Purpose: PurposeHTTP},
+		{Flag: "--host", Value: valueHost, Purpose: PurposeHTTP},
+		{Flag: "-p", Value: valuePort, Purpose: PurposeHTTP},
+		{Flag: "--port", Value: valuePort, Purpose: PurposeHTTP},
+	},
+	"memcached": {
+		{Flag: "-l", Value: valueHost},
+		{Flag: "-s", Value: valueSocket},
+		{Flag: "-U", Value: valuePort, Protocol: "udp"},
+	},
+	"mysqld": {
+		{Flag: "--bind-address", Value: valueHost},
+		{Flag: "--port", Value: valuePort},
+		{Flag: "--socket", Value: valueSocket},
+	},
+	"postgres": {
+		{Flag: "-h", Value: valueHost},
+	},
+	"mongod": {
+		{Flag: "--bind_ip", Value: valueHost},
+	},
+	"elasticsearch": {
+		{Flag: "-Ehttp.port", Attached: true, Value: valuePort, Purpose: PurposeHTTP},
+		{Flag: "-Ehttp.host", Attached: true, Value: valueHost, Purpose: PurposeHTTP},
+	},
+}
+
+// the environment variables that hold addresses
+var envEndpointRules = []endpointRule{
+	{Flag: "PORT", Value: valuePort},
+	{Flag: "SERVER_PORT", Value: valuePort, Purpose: PurposeHTTP},
+	{Flag: "ASPNETCORE_URLS", Value: valueURLs, Purpose: PurposeHTTP},
+}
+
+// Endpoints collects the addresses the command line tells the process to
+// listen on, from the flags of the runtime and of known products like
+// gunicorn or uvicorn, and from the Env assignments. The default port of a
+// product is only assumed for a flag that turns the listener on, like node
+// --inspect, otherwise only what the command line says is found. The generic
+// flags like --port are only read from the arguments of the program, and
+// not for the clients like psql or ssh.
+func (c *CommandLine) Endpoints() []Endpoint {
+	var (
+		endpoints []Endpoint
+		hosts     = map[string]string{}
+	)
+	add := func(index int, rule *endpointRule, value string) bool {
+		if rule.Value == valueHost {
+			if value == "" {
+				return false
+			}
+			hosts[rule.Purpose] = value
+			return true
+		}
+		found := parseEndpoints(rule, value)
+		for _, e := range found {
+			e.Index = index
+			endpoints = append(endpoints, e)
+		}
+		return len(found) > 0
+	}
+
+	for _, e := range c.Env {
+		name, value, _ := strings.Cut(e, "=")
+		for idx := range envEndpointRules {
+			if rule := &envEndpointRules[idx]; rule.Flag == name {
+				add(-1, rule, value)
+				break
+			}
+		}
+	}
+
+	// the other rules skip the options of the runtime, "java -p" is the
+	// module path
+	start := c.programArgsStart()
+	scanEndpointRules(c.Args, 0, runtimeEndpointRules[c.runtime()], add)
+
+	var rules []endpointRule
+	for _, name := range c.endpointProducts() {
+		rules = append(rules, productEndpointRules[name]...)
+	}
+	if !contains(endpointClients, strings.ToLower(executableName(c.isWindows(), c.programName()))) {
+		rules = append(rules, genericEndpointRules...)
+	}
+	scanEndpointRules(c.Args[start:], start, rules, add)
+
+	// the roles of a rule file
+	if c.Custom != nil {
+		for _, v := range c.Custom.Values {
+			switch v.Role {
+			case RolePort:
+				add(v.Index, &endpointRule{Value: valuePort}, v.Value)
+			case RoleAddress:
+				add(v.Index, &endpointRule{Value: valueAddress}, v.Value)
+			}
+		}
+	}
+
+	// "--host h --port p" is one endpoint
+	for idx := range endpoints {
+		e := &endpoints[idx]
+		if host, ok := hosts[e.Purpose]; ok && e.Host == "" && e.Protocol != "unix" {
+			e.Host = host
+		}
+	}
+	return dedupEndpoints(endpoints)
+}
+
+// endpointProducts names what runs, for the product rules
+func (c *CommandLine) endpointProducts() []string {
+	var names []string
+	add := func(name string) {
+		name = strings.ToLower(name)
+		if base, _ := splitVersion(name); base != "" {
+			name = strings.TrimRight(base, "-_")
+		}
+		if name != "" && !contains(names, name) {
+			names = append(names, name)
+		}
+	}
+
+	add(executableName(c.isWindows(), c.ExecutePath))
+	if c.Sub != nil {
+		add(executableName(c.isWindows(), c.Sub.Command))
+	}
+	add(c.ServiceName())
+	return names
+}
+
+func scanEndpointRules(args []string, offset int, rules []endpointRule, add func(index int, rule *endpointRule, value string) bool) {
+	for idx := 0; idx < len(args); idx++ {
+		a := args[idx]
+		for ridx := range rules {
+			rule := &rules[ridx]
+			if rule.Prefix {
+				if strings.HasPrefix(a, rule.Flag) && add(offset+idx, rule, a[len(rule.Flag):]) {
+					break
+				}
+				continue
+			}
+
+			if value, ok := strings.CutPrefix(a, rule.Flag+"="); ok {
+				if add(offset+idx, rule, value) {
+					break
+				}
+				continue
+			}
+			if a != rule.Flag {
+				continue
+			}
+			if !rule.Attached && idx+1 < len(args) && add(offset+idx+1, rule, args[idx+1]) {
+				idx++
+				break
+			}
+			if rule.Default != 0 {
+				add(offset+idx, rule, strconv.Itoa(rule.Default))
+				break
+			}
+		}
+	}
+}
+
+func parseEndpoints(rule *endpointRule, value string) []Endpoint {
+	var endpoints []Endpoint
+	switch rule.Value {
+	case valuePort:
+		if port, ok := parsePort(value); ok {
+			endpoints = append(endpoints, Endpoint{Port: port})
+		}
+	case valueAddress:
+		if e, ok := parseAddress(value); ok {
+			endpoints = append(endpoints, e)
+		}
+	case valueURLs:
+		for _, s := range strings.FieldsFunc(value, func(r rune) bool { return r == ';' || r == ',' }) {
+			if e, ok := parseAddress(strings.TrimSpace(s)); ok {
+				endpoints = append(endpoints, e)
+			}
+		}
+	case valueSocket:
+		if value != "" {
+			endpoints = append(endpoints, Endpoint{Protocol: "unix", Path: value})
+		}
+	case valueJDWP:
+		for _, opt := range strings.Split(value, ",") {
+			if address, ok := strings.CutPrefix(opt, "address="); ok {
+				if e, ok := parseAddress(address); ok {
+					endpoints = append(endpoints, e)
+				}
+			}
+		}
+	case valueJMXExporter:
+		// -javaagent:/opt/jmx_prometheus_javaagent.jar=[host:]port:config.yml
+		jar, options, ok := strings.Cut(value, "=")
+		if !ok || !strings.Contains(strings.ToLower(scriptBase(jar)), "jmx_prometheus") {
+			break
+		}
+		if idx := strings.LastIndexByte(options, ':'); idx > 0 {
+			if _, err := strconv.Atoi(options[idx+1:]); err != nil {
+				options = options[:idx]
+			}
+		}
+		if e, ok := parseAddress(options); ok {
+			endpoints = append(endpoints, e)
+		}
+	}
+
+	for idx := range endpoints {
+		e := &endpoints[idx]
+		if e.Protocol == "" {
+			e.Protocol = "tcp"
+			if rule.Protocol != "" {
+				e.Protocol = rule.Protocol
+			}
+		}
+		if rule.Purpose != "" {
+			e.Purpose = rule.Purpose
+		}
+	}
+	return endpoints
+}
+
+// parseAddress parses "8080", ":8080", "host:8080", "[::1]:8080",
+// "tcp://host:8080", "http://host:8080" and the unix sockets "unix:/path"
+// and "unix:///path"
+func parseAddress(s string) (Endpoint, bool) {
+	if path, ok := strings.CutPrefix(s, "unix:"); ok {
+		path = strings.TrimPrefix(path, "//")
+		if path == "" {
+			return Endpoint{}, false
+		}
+		return Endpoint{Protocol: "unix", Path: path}, true
+	}
+
+	if strings.Contains(s, "://") {
+		u, err := url.Parse(s)
+		if err != nil {
+			return Endpoint{}, false
+		}
+		e := Endpoint{Host: u.Hostname()}
+		switch u.Scheme {
+		case "http":
+			e.Port, e.Purpose = 80, PurposeHTTP
+		case "https":
+			e.Port, e.Purpose = 443, PurposeHTTP
+		case "tcp":
+		case "udp":
+			e.Protocol = "udp"
+		default:
+			return Endpoint{}, false
+		}
+		if u.Port() != "" {
+			port, ok := parsePort(u.Port())
+			if !ok {
+				return Endpoint{}, false
+			}
+			e.Port = port
+		}
+		return e, e.Port != 0
+	}
+
+	if port, ok := parsePort(s); ok {
+		return Endpoint{Port: port}, true
+	}
+	host, portString, err := net.SplitHostPort(s)
+	if err != nil {
+		return Endpoint{}, false
+	}
+	port, ok := parsePort(portString)
+	if !ok {
+		return Endpoint{}, false
+	}
+	return Endpoint{Host: host, Port: port}, true
+}
+
+func parsePort(s string) (int, bool) {
+	port, err := strconv.Atoi(s)
+	if err != nil || port <= 0 || port > 65535 || s[0] == '+' {
+		return 0, false
+	}
+	return port, true
+}
+
+func dedupEndpoints(endpoints []Endpoint) []Endpoint {
+	var deduped []Endpoint
+	for _, e := range endpoints {
+		duplicate := false
+		for _, d := range deduped {
+			if d.Host == e.Host && d.Port == e.Port && d.Protocol == e.Protocol && d.Path == e.Path && d.Purpose == e.Purpose {
+				duplicate = true
+				break
+			}
+		}
+		if !duplicate {
+			deduped = append(deduped, e)
+		}
+	}
+	return deduped
+}
@@ -0,0 +1,184 @@
+package cmdline
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEndpoints(t *testing.T) {
+	tests := []struct {
+		isWindows bool
+		cmdline   string
+		expected  []Endpoint
+	}{
+		{
+			cmdline: "redis-server /etc/redis.conf --port 6380",
+			expected: []Endpoint{
+				{Port: 6380, Protocol: "tcp", Index: 2},
+			},
+		},
+		{
+			cmdline: "/usr/bin/app --host=127.0.0.1 --port=8080 --metrics-port 9100",
+			expected: []Endpoint{
+				{Host: "127.0.0.1", Port: 8080, Protocol: "tcp", Index: 1},
+				{Port: 9100, Protocol: "tcp", Purpose: PurposeMetrics, Index: 3},
+			},
+		},
+		{
+			cmdline: "gunicorn -w 8 -b 0.0.0.0:8000 --bind unix:/run/gunicorn.sock app:app",
+			expected: []Endpoint{
+				{Host: "0.0.0.0", Port: 8000, Protocol: "tcp", Purpose: PurposeHTTP, Index: 3},
+				{Protocol: "unix", Path: "/run/gunicorn.sock", Purpose: PurposeHTTP, Index: 5},
+			},
+		},
+		{
+			cmdline: "python3 -m uvicorn main:app --host 0.0.0.0 --port 8000",
+			expected: []Endpoint{
+				{Host: "0.0.0.0", Port: 8000, Protocol: "tcp", Purpose: PurposeHTTP, Index: 6},
+			},
+		},
+		{
+			cmdline: "python manage.py runserver 0.0.0.0:8001",
+			expected: []Endpoint{
+				{Host: "0.0.0.0", Port: 8001, Protocol: "tcp", Purpose: PurposeHTTP, Index: 2},
+			},
+		},
+		{
+			cmdline: "python manage.py runserver",
+			expected: []Endpoint{
+				{Port: 8000, Protocol: "tcp", Purpose: PurposeHTTP, Index: 1},
+			},
+		},
+		{
+			cmdline: "sudo -u app /usr/local/bin/puma -b tcp://127.0.0.1:9292 -e production",
+			expected: []Endpoint{
+				{Host: "127.0.0.1", Port: 9292, Protocol: "tcp", Purpose: PurposeHTTP, Index: 4},
+			},
+		},
+		{
+			cmdline: "java -Dserver.port=8080 -Dcom.sun.management.jmxremote.port=9010 -Djava.rmi.server.hostname=10.0.0.5 " +
+				"-agentlib:jdwp=transport=dt_socket,server=y,suspend=n,address=*:5005 " +
+				"-javaagent:/opt/jmx_prometheus_javaagent.jar=9404:/etc/jmx.yml -javaagent:/opt/dd-java-agent.jar -jar /opt/app.jar",
+			expected: []Endpoint{
+				{Port: 8080, Protocol: "tcp", Purpose: PurposeHTTP, Index: 0},
+				{Host: "10.0.0.5", Port: 9010, Protocol: "tcp", Purpose: PurposeJMX, Index: 1},
+				{Host: "*", Port: 5005, Protocol: "tcp", Purpose: PurposeDebug, Index: 3},
+				{Port: 9404, Protocol: "tcp", Purpose: PurposeMetrics, Index: 4},
+			},
+		},
+		{
+			cmdline: "java -Xrunjdwp:transport=dt_socket,address=8000 -cp /opt/lib com.example.Main --server.port=8081",
+			expected: []Endpoint{
+				{Port: 8000, Protocol: "tcp", Purpose: PurposeDebug, Index: 0},
+				{Port: 8081, Protocol: "tcp", Purpose: PurposeHTTP, Index: 4},
+			},
+		},
+		{
+			cmdline: "node --inspect server.js --port 3000",
+			expected: []Endpoint{
+				{Port: 9229, Protocol: "tcp", Purpose: PurposeDebug, Index: 0},
+				{Port: 3000, Protocol: "tcp", Index: 3},
+			},
+		},
+		{
+			cmdline: "node --inspect=0.0.0.0:9230 server.js",
+			expected: []Endpoint{
+				{Host: "0.0.0.0", Port: 9230, Protocol: "tcp", Purpose: PurposeDebug, Index: 0},
+			},
+		},
+		{
+			cmdline: `ASPNETCORE_URLS="http://*:5000;https://localhost:5001" dotnet app.dll --urls http://+:5002`,
+			expected: []Endpoint{
+				{Host: "*", Port: 5000, Protocol: "tcp", Purpose: PurposeHTTP, Index: -1},
+				{Host: "localhost", Port: 5001, Protocol: "tcp", Purpose: PurposeHTTP, Index: -1},
+				{Host: "+", Port: 5002, Protocol: "tcp", Purpose: PurposeHTTP, Index: 2},
+			},
+		},
+		{
+			cmdline: "memcached -m 64 -p 11211 -U 11211 -l 127.0.0.1",
+			expected: []Endpoint{
+				{Host: "127.0.0.1", Port: 11211, Protocol: "tcp", Index: 3},
+				{Host: "127.0.0.1", Port: 11211, Protocol: "udp", Index: 5},
+			},
+		},
+		{
+			cmdline: "node_exporter --web.listen-address=[::1]:9100",
+			expected: []Endpoint{
+				{Host: "::1", Port: 9100, Protocol: "tcp", Purpose: PurposeMetrics, Index: 0},
+			},
+		},
+		{
+			// not ports
+			cmdline:  "mysql -uroot -p mydb --port=99999",
+			expected: nil,
+		},
+		{
+			cmdline:  "java -p /opt/mods -jar /opt/app.jar",
+			expected: nil,
+		},
+		{
+			// the clients connect to the port
+			cmdline:  "psql -h db -p 5432 -U app",
+			expected: nil,
+		},
+		{
+			cmdline:  "mysql -h db -P 3306 -p",
+			expected: nil,
+		},
+		{
+			cmdline:  "/usr/bin/ssh -p 22 -N -L 8080:localhost:80 host",
+			expected: nil,
+		},
+		{
+			cmdline:  "redis-cli --port 6379 ping",
+			expected: nil,
+		},
+		{
+			// the options of python are not the options of the script
+			cmdline: "python3 -X dev app.py --port 8000",
+			expected: []Endpoint{
+				{Port: 8000, Protocol: "tcp", Index: 4},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		c, err := ParseCommandLine(tt.isWindows, tt.cmdline)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		assert.Equal(t, tt.expected, c.Endpoints(), tt.cmdline)
+	}
+}
+
+func TestParseAddress(t *testing.T) {
+	tests := []struct {
+		s        string
+		expected Endpoint
+		ok       bool
+	}{
+		{s: "8080", expected: Endpoint{Port: 8080}, ok: true},
+		{s: ":8080", expected: Endpoint{Port: 8080}, ok: true},
+		{s: "localhost:8080", expected: Endpoint{Host: "localhost", Port: 8080}, ok: true},
+		{s: "[::]:8080", expected: Endpoint{Host: "::", Port: 8080}, ok: true},
+		{s: "http://example.com", expected: Endpoint{Host: "example.com", Port: 80, Purpose: PurposeHTTP}, ok: true},
+		{s: "udp://0.0.0.0:8125", expected: Endpoint{Host: "0.0.0.0", Port: 8125, Protocol: "udp"}, ok: true},
+		{s: "unix:///tmp/app.sock", expected: Endpoint{Protocol: "unix", Path: "/tmp/app.sock"}, ok: true},
+		{s: "unix:", ok: false},
+		{s: "ftp://example.com:21", ok: false},
+		{s: "host:port", ok: false},
+		{s: "0", ok: false},
+		{s: "+80", ok: false},
+		{s: "app.js", ok: false},
+	}
+
+	for _, tt := range tests {
+		e, ok := parseAddress(tt.s)
+		assert.Equal(t, tt.ok, ok, tt.s)
+		if tt.ok {
+			assert.Equal(t, tt.expected, e, tt.s)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package cmdline
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrNoScript means python or ruby runs no script file or module
+	ErrNoScript = errors.New("scriptfile not found")
+	// ErrNoMainClass means java runs neither a main class nor a jar
+	ErrNoMainClass = errors.New("classname not found")
+	// ErrNoCommand means a wrapper like sudo runs no command
+	ErrNoCommand = errors.New("command not found")
+	// ErrNoComponent means a windows service host hosts nothing known
+	ErrNoComponent = errors.New("component not found")
+	// ErrLinkLoop means the links of the executable don't end, see WithFS
+	ErrLinkLoop = errors.New("too many levels of symbolic links")
+)
+
+// TokenizeError means the command line can't be split into arguments, e.g.
+// because of an unterminated quote. It is a hard failure, no CommandLine is
+// returned with it.
+type TokenizeError struct {
+	Input string
+	// Offset is the byte offset in Input where the problem starts
+	Offset int
+	Reason string
+}
+
+func (e *TokenizeError) Error() string {
+	return "invalid command - " + e.Reason + " at offset " + strconv.Itoa(e.Offset) + " in `" + e.Input + "`"
+}
+
+// UnknownOptionError means an option the extractor doesn't know
+type UnknownOptionError struct {
+	Executable string
+	Option     string
+	// Index is the index of the option in Args
+	Index int
+}
+
+func (e *UnknownOptionError) Error() string {
+	return "unknown option " + strconv.Quote(e.Option) + " of " + e.Executable
+}
+
+// Diagnostic is a problem with a command line that still gives a partial
+// result, like a python without a script file.
+type Diagnostic struct {
+	// Index is the index in Args the diagnostic is about, -1 if it is about
+	// the whole command line
+	Index int
+	Err   error
+}
+
+func (d Diagnostic) String() string {
+	if d.Index < 0 {
+		return d.Err.Error()
+	}
+	return "args[" + strconv.Itoa(d.Index) + "]: " + d.Err.Error()
+}
+
+// diagnosticError gives back the sentinel errors when the diagnostics are
+// decoded from their JSON or YAML form
+func diagnosticError(message string) error {
+	for _, err := range []error{ErrNoScript, ErrNoMainClass, ErrNoCommand, ErrNoComponent, ErrLinkLoop} {
+		if message == err.Error() {
+			return err
+		}
+		if prefix, ok := strings.CutSuffix(message, ": "+err.Error()); ok {
+			return fmt.Errorf("%s: %w", prefix, err)
+		}
+	}
+	return errors.New(message)
+}
+
+// shellTokenizeError finds where the shell rules fail on s, the tokenizer
+// itself doesn't tell
+func shellTokenizeError(s string) *TokenizeError {
+	var (
+		quote    byte
+		quoteAt  int
+		escaped  bool
+		escapeAt int
+		parens   []int
+		reason   = "invalid command line string"
+		reasonAt = len(s)
+	)
+
+	for idx := 0; idx < len(s); idx++ {
+		c := s[idx]
+		switch {
+		case escaped:
+			escaped = false
+		case quote == '\'':
+			if c == '\'' {
+				quote = 0
+			}
+		case c == '\\':
+			escaped, escapeAt = true, idx
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote, quoteAt = c, idx
+		case c == '(':
+			if idx == 0 || s[idx-1] != '$' {
+				return &TokenizeError{Input: s, Offset: idx, Reason: "unexpected '('"}
+			}
+			parens = append(parens, idx)
+		case c == ')' && len(parens) > 0:
+			parens = parens[:len(parens)-1]
+		}
+	}
+
+	switch {
+	case escaped:
+		reason, reasonAt = "trailing backslash", escapeAt
+	case quote == '"':
+		reason, reasonAt = "unterminated double quote", quoteAt
+	case quote == '\'':
+		reason, reasonAt = "unterminated single quote", quoteAt
+	case quote == '`':
+		reason, reasonAt = "unterminated backquote", quoteAt
+	case len(parens) > 0:
+		reason, reasonAt = "unterminated $(", parens[0]-1
+	}
+	return &TokenizeError{Input: s, Offset: reasonAt, Reason: reason}
+}
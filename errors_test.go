@@ -0,0 +1,96 @@
+package cmdline
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		isWindows bool
+		cmdline   string
+		expected  error
+	}{
+		{cmdline: "python3 -u", expected: ErrNoScript},
+		{cmdline: "ruby -w", expected: ErrNoScript},
+		{cmdline: "java -version", expected: ErrNoMainClass},
+		{cmdline: "sudo -E", expected: ErrNoCommand},
+		{isWindows: true, cmdline: `C:\Windows\system32\svchost.exe`, expected: ErrNoComponent},
+		{isWindows: true, cmdline: `rundll32.exe`, expected: ErrNoComponent},
+	}
+
+	for _, tt := range tests {
+		c, err := ParseCommandLine(tt.isWindows, tt.cmdline)
+		if !errors.Is(err, tt.expected) {
+			t.Error("[", tt.cmdline, "] want", tt.expected, "got", err)
+			continue
+		}
+		if assert.Len(t, c.Diagnostics, 1) {
+			assert.Equal(t, -1, c.Diagnostics[0].Index)
+			assert.True(t, errors.Is(c.Diagnostics[0].Err, tt.expected))
+		}
+
+		lenient, err := NewParser(WithFlavor(flavorOf(tt.isWindows)), WithStrictness(StrictnessLenient)).ParseCommandLine(tt.cmdline)
+		if err != nil {
+			t.Error("[", tt.cmdline, "] lenient want ok got", err)
+			continue
+		}
+		assert.Equal(t, c, lenient)
+	}
+}
+
+func TestTokenizeError(t *testing.T) {
+	tests := []struct {
+		cmdline string
+		offset  int
+		reason  string
+	}{
+		{cmdline: `java -Dname="a b`, offset: 12, reason: "unterminated double quote"},
+		{cmdline: `java 'a\" b`, offset: 5, reason: "unterminated single quote"},
+		{cmdline: `java "a\" b`, offset: 5, reason: "unterminated double quote"},
+		{cmdline: `java a\`, offset: 6, reason: "trailing backslash"},
+		{cmdline: "java `date", offset: 5, reason: "unterminated backquote"},
+		{cmdline: `java (a)`, offset: 5, reason: "unexpected '('"},
+		{cmdline: `   `, offset: 3, reason: "no executable"},
+	}
+
+	for _, tt := range tests {
+		c, err := NewParser(WithStrictness(StrictnessLenient)).ParseCommandLine(tt.cmdline)
+		if c != nil {
+			t.Error("[", tt.cmdline, "] want nil got", c)
+		}
+
+		var tokenizeErr *TokenizeError
+		if !errors.As(err, &tokenizeErr) {
+			t.Error("[", tt.cmdline, "] want TokenizeError got", err)
+			continue
+		}
+		assert.Equal(t, tt.cmdline, tokenizeErr.Input)
+		assert.Equal(t, tt.offset, tokenizeErr.Offset, tt.cmdline)
+		assert.Equal(t, tt.reason, tokenizeErr.Reason, tt.cmdline)
+	}
+
+	_, err := ParseCommandLine(true, "java a\x00b")
+	var tokenizeErr *TokenizeError
+	if assert.True(t, errors.As(err, &tokenizeErr)) {
+		assert.Equal(t, 6, tokenizeErr.Offset)
+	}
+}
+
+func TestUnknownOptionDiagnostic(t *testing.T) {
+	c, err := ParseCommandLine(true, `C:\Windows\system32\svchost.exe -k netsvcs -z -s Schedule`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if assert.Len(t, c.Diagnostics, 1) {
+		var unknown *UnknownOptionError
+		if assert.True(t, errors.As(c.Diagnostics[0].Err, &unknown)) {
+			assert.Equal(t, "-z", unknown.Option)
+			assert.Equal(t, 2, unknown.Index)
+		}
+		assert.Equal(t, `args[2]: unknown option "-z" of svchost`, c.Diagnostics[0].String())
+	}
+}
@@ -0,0 +1,184 @@
+package cmdline
+
+import (
+	"strings"
+)
+
+// Expansion records an argument with environment references that were
+// expanded, see WithEnv
+type Expansion struct {
+	// Index is the index in Args, -1 for ExecutePath
+	Index int
+	// Original is the argument before the expansion
+	Original string
+}
+
+// expand expands the environment references of exe and args, args is copied
+// when anything is expanded
+func (p *Parser) expand(exe string, args []string) (string, []string, []Expansion) {
+	var expansions []Expansion
+
+	if value, ok := p.expandString(exe); ok {
+		expansions = append(expansions, Expansion{Index: -1, Original: exe})
+		exe = value
+	}
+
+	copied := false
+	for idx, a := range args {
+		value, ok := p.expandString(a)
+		if !ok {
+			continue
+		}
+		if !copied {
+			args = append([]string(nil), args...)
+			copied = true
+		}
+		expansions = append(expansions, Expansion{Index: idx, Original: a})
+		args[idx] = value
+	}
+	return exe, args, expansions
+}
+
+func (p *Parser) expandString(s string) (string, bool) {
+	if p.isWindows() {
+		return expandWindows(s, p.env)
+	}
+	return expandPOSIX(s, p.env)
+}
+
+// expandPOSIX expands $VAR, ${VAR}, ${VAR:-default} and ${VAR-default} the
+// way the shell does, except that the references to variables missing from
+// env are kept as they are.
+func expandPOSIX(s string, env map[string]string) (string, bool) {
+	if !strings.Contains(s, "$") {
+		return s, false
+	}
+
+	var sb strings.Builder
+	expanded := false
+	for idx := 0; idx < len(s); {
+		if s[idx] != '$' || idx+1 == len(s) {
+			sb.WriteByte(s[idx])
+			idx++
+			continue
+		}
+
+		if s[idx+1] == '{' {
+			if end := closingBrace(s[idx+2:]); end >= 0 {
+				if value, ok := lookupPOSIX(s[idx+2:idx+2+end], env); ok {
+					sb.WriteString(value)
+					expanded = true
+					idx += end + 3
+					continue
+				}
+			}
+		} else if n := posixNameLen(s[idx+1:]); n > 0 {
+			if value, ok := env[s[idx+1:idx+1+n]]; ok {
+				sb.WriteString(value)
+				expanded = true
+				idx += n + 1
+				continue
+			}
+		}
+		sb.WriteByte('$')
+		idx++
+	}
+	return sb.String(), expanded
+}
+
+// lookupPOSIX looks up the inside of ${...}
+func lookupPOSIX(ref string, env map[string]string) (string, bool) {
+	n := posixNameLen(ref)
+	if n == 0 {
+		return "", false
+	}
+
+	name, op := ref[:n], ref[n:]
+	value, ok := env[name]
+	switch {
+	case op == "":
+		return value, ok
+	case strings.HasPrefix(op, ":-"):
+		if ok && value != "" {
+			return value, true
+		}
+		value, _ = expandPOSIX(op[2:], env)
+		return value, true
+	case strings.HasPrefix(op, "-"):
+		if ok {
+			return value, true
+		}
+		value, _ = expandPOSIX(op[1:], env)
+		return value, true
+	}
+	return "", false
+}
+
+// closingBrace finds the '}' that closes a "${", skipping the nested ones
+func closingBrace(s string) int {
+	depth := 0
+	for idx := 0; idx < len(s); idx++ {
+		switch {
+		case s[idx] == '$' && idx+1 < len(s) && s[idx+1] == '{':
+			depth++
+			idx++
+		case s[idx] == '}':
+			if depth == 0 {
+				return idx
+			}
+			depth--
+		}
+	}
+	return -1
+}
+
+func posixNameLen(s string) int {
+	for idx := 0; idx < len(s); idx++ {
+		c := s[idx]
+		switch {
+		case c == '_' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z':
+		case '0' <= c && c <= '9' && idx > 0:
+		default:
+			return idx
+		}
+	}
+	return len(s)
+}
+
+// expandWindows expands %VAR% the way cmd does, the names are case
+// insensitive and the references to variables missing from env are kept.
+func expandWindows(s string, env map[string]string) (string, bool) {
+	if !strings.Contains(s, "%") {
+		return s, false
+	}
+
+	var sb strings.Builder
+	expanded := false
+	for idx := 0; idx < len(s); {
+		if s[idx] == '%' {
+			if end := strings.IndexByte(s[idx+1:], '%'); end > 0 {
+				if value, ok := lookupWindows(s[idx+1:idx+1+end], env); ok {
+					sb.WriteString(value)
+					expanded = true
+					idx += end + 2
+					continue
+				}
+			}
+		}
+		sb.WriteByte(s[idx])
+		idx++
+	}
+	return sb.String(), expanded
+}
+
+func lookupWindows(name string, env map[string]string) (string, bool) {
+	if value, ok := env[name]; ok {
+		return value, true
+	}
+	for key, value := range env {
+		if strings.EqualFold(key, name) {
+			return value, true
+		}
+	}
+	return "", false
+}
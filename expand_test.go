@@ -0,0 +1,109 @@
+package cmdline
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpandPOSIX(t *testing.T) {
+	env := map[string]string{
+		"HOME":      "/home/dog",
+		"JAVA_HOME": "/usr/lib/jvm/java-17",
+		"EMPTY":     "",
+		"PORT":      "8080",
+	}
+
+	tests := []struct {
+		s        string
+		expected string
+		expanded bool
+	}{
+		{s: "plain", expected: "plain"},
+		{s: "$HOME/.config", expected: "/home/dog/.config", expanded: true},
+		{s: "${JAVA_HOME}/bin/java", expected: "/usr/lib/jvm/java-17/bin/java", expanded: true},
+		{s: "--port=$PORT", expected: "--port=8080", expanded: true},
+		{s: "${PORT:-80}", expected: "8080", expanded: true},
+		{s: "${MISSING:-80}", expected: "80", expanded: true},
+		{s: "${EMPTY:-80}", expected: "80", expanded: true},
+		{s: "${EMPTY-80}", expected: "", expanded: true},
+		{s: "${MISSING-80}", expected: "80", expanded: true},
+		{s: "${MISSING:-$HOME/x}", expected: "/home/dog/x", expanded: true},
+		{s: "${MISSING:-${PORT}}", expected: "8080", expanded: true},
+		{s: "$MISSING/x", expected: "$MISSING/x"},
+		{s: "${MISSING}", expected: "${MISSING}"},
+		{s: "${HOME", expected: "${HOME"},
+		{s: "${HOME:?error}", expected: "${HOME:?error}"},
+		{s: "cost $5 $", expected: "cost $5 $"},
+		{s: "$HOME$PORT", expected: "/home/dog8080", expanded: true},
+		{s: "$HOMEX", expected: "$HOMEX"},
+	}
+
+	for _, tt := range tests {
+		actual, expanded := expandPOSIX(tt.s, env)
+		assert.Equal(t, tt.expected, actual, tt.s)
+		assert.Equal(t, tt.expanded, expanded, tt.s)
+	}
+}
+
+func TestExpandWindows(t *testing.T) {
+	env := map[string]string{
+		"JAVA_HOME":    `C:\Program Files\Java\jdk-17`,
+		"ProgramFiles": `C:\Program Files`,
+	}
+
+	tests := []struct {
+		s        string
+		expected string
+		expanded bool
+	}{
+		{s: "plain", expected: "plain"},
+		{s: `%JAVA_HOME%\bin\java.exe`, expected: `C:\Program Files\Java\jdk-17\bin\java.exe`, expanded: true},
+		{s: `%PROGRAMFILES%\app`, expected: `C:\Program Files\app`, expanded: true},
+		{s: `%MISSING%\app`, expected: `%MISSING%\app`},
+		{s: `100%`, expected: `100%`},
+		{s: `%%`, expected: `%%`},
+		{s: `%MISSING%JAVA_HOME%`, expected: `%MISSING` + `C:\Program Files\Java\jdk-17`, expanded: true},
+	}
+
+	for _, tt := range tests {
+		actual, expanded := expandWindows(tt.s, env)
+		assert.Equal(t, tt.expected, actual, tt.s)
+		assert.Equal(t, tt.expanded, expanded, tt.s)
+	}
+}
+
+func TestParseEnv(t *testing.T) {
+	c, err := ParseCommandLine(false, "JAVA_OPTS=-Xmx1g LANG=C java -jar $APP_HOME/app.jar")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"JAVA_OPTS=-Xmx1g", "LANG=C"}, c.Env)
+	assert.Equal(t, "java", c.ExecutePath)
+	assert.Equal(t, "$APP_HOME/app.jar", c.Java.ClassName)
+	assert.Nil(t, c.Expansions)
+	assert.Equal(t, "JAVA_OPTS=-Xmx1g LANG=C java -jar '$APP_HOME/app.jar'", c.String(QuotePOSIX))
+
+	c, err = ParseCommandLine(false, "java -jar app.jar")
+	assert.NoError(t, err)
+	assert.Nil(t, c.Env)
+
+	p := NewParser(WithEnv(map[string]string{"APP_HOME": "/opt/app", "JAVA_HOME": "/usr/lib/jvm/java-17"}))
+	args := []string{"-jar", "$APP_HOME/app.jar", "--name", "$NAME"}
+	c, err = p.Parse("$JAVA_HOME/bin/java", args)
+	assert.NoError(t, err)
+	assert.Equal(t, "/usr/lib/jvm/java-17/bin/java", c.ExecutePath)
+	assert.Equal(t, "/opt/app/app.jar", c.Java.ClassName)
+	assert.Equal(t, []string{"--name", "$NAME"}, c.Java.Args)
+	assert.Equal(t, []Expansion{
+		{Index: -1, Original: "$JAVA_HOME/bin/java"},
+		{Index: 1, Original: "$APP_HOME/app.jar"},
+	}, c.Expansions)
+	// the arguments of the caller are left alone
+	assert.Equal(t, "$APP_HOME/app.jar", args[1])
+
+	p = NewParser(WithFlavor(FlavorWindows), WithEnv(map[string]string{"JAVA_HOME": `C:\jdk`}))
+	c, err = p.ParseCommandLine(`"%JAVA_HOME%\bin\java.exe" -cp "%CLASSPATH%" com.example.Main`)
+	assert.NoError(t, err)
+	assert.Equal(t, `C:\jdk\bin\java.exe`, c.ExecutePath)
+	assert.Equal(t, "com.example.Main", c.Java.ClassName)
+	assert.Equal(t, []Expansion{{Index: -1, Original: `%JAVA_HOME%\bin\java.exe`}}, c.Expansions)
+}
@@ -0,0 +1,127 @@
+package cmdline
+
+import (
+	"strconv"
+)
+
+// TokenRole is how the parser read a token of the command line
+type TokenRole int
+
+const (
+	// TokenArgument is an argument of the program, or a token no extractor
+	// knows
+	TokenArgument TokenRole = iota
+	// TokenEnv is a "NAME=value" assignment, in front of the executable or
+	// of the command of a wrapper
+	TokenEnv
+	// TokenExecutable is the executable, or the command of a wrapper
+	TokenExecutable
+	// TokenWrapperOption is an option of a wrapper like sudo
+	TokenWrapperOption
+	// TokenRuntimeOption is an option of a runtime like java or python
+	TokenRuntimeOption
+	// TokenOptionValue is the value of the option before it
+	TokenOptionValue
+	// TokenEndOfOptions is the "--" that ends the options
+	TokenEndOfOptions
+	// TokenTarget is what the executable runs: the command of a wrapper,
+	// the script, module, main class or jar of a runtime
+	TokenTarget
+)
+
+func (r TokenRole) String() string {
+	switch r {
+	case TokenArgument:
+		return "program argument"
+	case TokenEnv:
+		return "environment assignment"
+	case TokenExecutable:
+		return "executable"
+	case TokenWrapperOption:
+		return "wrapper option"
+	case TokenRuntimeOption:
+		return "runtime option"
+	case TokenOptionValue:
+		return "option value"
+	case TokenEndOfOptions:
+		return "end of options"
+	case TokenTarget:
+		return "main target"
+	default:
+		return "TokenRole(" + strconv.Itoa(int(r)) + ")"
+	}
+}
+
+// TokenAnnotation is a token of the command line with the role an extractor
+// gave it
+type TokenAnnotation struct {
+	Token string
+	Role  TokenRole
+	// Executable is the name of the executable that reads the token, the
+	// wrapped command for the tokens after a wrapper
+	Executable string
+	// Depth is how many wrappers the executable is wrapped in
+	Depth int
+	// Option is the option of a TokenOptionValue, or of a TokenTarget like
+	// java -jar
+	Option string
+	// Value is the value of an option, attached or in the next token
+	Value string
+	// Unknown is set for the options the extractor doesn't know
+	Unknown bool
+}
+
+// ParseExplained parses s like ParseCommandLine and annotates every token
+// with its role, in the order of the command line. The roles come from the
+// extractors while they parse, the wrapped commands are annotated as deep
+// as WithMaxDepth parses them. The cache isn't used.
+func (p *Parser) ParseExplained(s string) (*CommandLine, []TokenAnnotation, error) {
+	explaining := *p
+	explaining.explain = true
+	explaining.cache = nil
+
+	c, err := explaining.parseCommandLine(s)
+	if c == nil {
+		return nil, nil, err
+	}
+	return c, explainTokens(c, 0, nil), err
+}
+
+// ParseExplained is short for NewParser(WithFlavor(...)).ParseExplained(s)
+func ParseExplained(isWindows bool, s string) (*CommandLine, []TokenAnnotation, error) {
+	return defaultParser(isWindows).ParseExplained(s)
+}
+
+// explainTokens appends the annotations of c and of its wrapped commands,
+// the tracers are removed on the way
+func explainTokens(c *CommandLine, depth int, tokens []TokenAnnotation) []TokenAnnotation {
+	trace := c.trace
+	c.trace = nil
+	// an empty command line has no tokens, unlike an empty wrapped command
+	if depth == 0 && c.ExecutePath == "" && len(c.Args) == 0 {
+		return tokens
+	}
+
+	name := executableName(c.isWindows(), c.ExecutePath)
+	for _, e := range c.Env {
+		tokens = append(tokens, TokenAnnotation{Token: e, Role: TokenEnv, Executable: name, Depth: depth})
+	}
+	tokens = append(tokens, TokenAnnotation{Token: c.ExecutePath, Role: TokenExecutable, Executable: name, Depth: depth})
+
+	end := len(c.Args)
+	if c.Sub != nil && c.Sub.CommandLine != nil {
+		end = len(c.Args) - len(c.Sub.Args) - 1
+	}
+	for idx := 0; idx < end; idx++ {
+		a := TokenAnnotation{Token: c.Args[idx], Executable: name, Depth: depth}
+		if trace != nil && trace.tokens != nil {
+			t := trace.tokens[idx]
+			a.Role, a.Option, a.Value, a.Unknown = t.role, t.option, t.value, t.unknown
+		}
+		tokens = append(tokens, a)
+	}
+	if end < len(c.Args) {
+		tokens = explainTokens(c.Sub.CommandLine, depth+1, tokens)
+	}
+	return tokens
+}
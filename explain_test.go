@@ -0,0 +1,91 @@
+package cmdline
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseExplained(t *testing.T) {
+	const cmdline = "LANG=C sudo -u dog -- TZ=UTC java -Xmx1g --frobnicate -jar app.jar --port 80"
+	c, tokens, err := NewParser(WithMaxDepth(1)).ParseExplained(cmdline)
+	assert.NoError(t, err)
+	assert.Equal(t, []TokenAnnotation{
+		{Token: "LANG=C", Role: TokenEnv, Executable: "sudo"},
+		{Token: "sudo", Role: TokenExecutable, Executable: "sudo"},
+		{Token: "-u", Role: TokenWrapperOption, Executable: "sudo", Value: "dog"},
+		{Token: "dog", Role: TokenOptionValue, Executable: "sudo", Option: "-u"},
+		{Token: "--", Role: TokenEndOfOptions, Executable: "sudo"},
+		{Token: "TZ=UTC", Role: TokenEnv, Executable: "sudo"},
+		{Token: "java", Role: TokenExecutable, Executable: "java", Depth: 1},
+		{Token: "-Xmx1g", Role: TokenRuntimeOption, Executable: "java", Depth: 1, Value: "mx1g"},
+		{Token: "--frobnicate", Role: TokenRuntimeOption, Executable: "java", Depth: 1, Unknown: true},
+		{Token: "-jar", Role: TokenRuntimeOption, Executable: "java", Depth: 1, Value: "app.jar"},
+		{Token: "app.jar", Role: TokenTarget, Executable: "java", Depth: 1, Option: "-jar"},
+		{Token: "--port", Role: TokenArgument, Executable: "java", Depth: 1},
+		{Token: "80", Role: TokenArgument, Executable: "java", Depth: 1},
+	}, tokens)
+
+	// the result is the one of ParseCommandLine
+	expected, _ := NewParser(WithMaxDepth(1)).ParseCommandLine(cmdline)
+	assert.Equal(t, expected, c)
+
+	_, tokens, err = ParseExplained(false, "sudo -u dog java -jar app.jar")
+	assert.NoError(t, err)
+	assert.Equal(t, []TokenRole{TokenExecutable, TokenWrapperOption, TokenOptionValue, TokenTarget, TokenArgument, TokenArgument}, tokenRoles(tokens))
+
+	_, tokens, err = ParseExplained(false, "python3 -u -c 'print(1)'")
+	assert.ErrorIs(t, err, ErrNoScript)
+	assert.Equal(t, []TokenRole{TokenExecutable, TokenRuntimeOption, TokenRuntimeOption, TokenOptionValue}, tokenRoles(tokens))
+
+	_, tokens, err = ParseExplained(false, "python3 -m http.server 8000")
+	assert.NoError(t, err)
+	assert.Equal(t, []TokenRole{TokenExecutable, TokenRuntimeOption, TokenTarget, TokenArgument}, tokenRoles(tokens))
+
+	_, tokens, err = ParseExplained(false, "nginx -g 'daemon off;'")
+	assert.NoError(t, err)
+	assert.Equal(t, []TokenRole{TokenExecutable, TokenArgument, TokenArgument}, tokenRoles(tokens))
+
+	// the common flags of the jvm are known
+	_, tokens, err = ParseExplained(false, "java -server -ea -XX:+UseG1GC kafka.Kafka")
+	assert.NoError(t, err)
+	assert.Equal(t, []TokenRole{TokenExecutable, TokenRuntimeOption, TokenRuntimeOption, TokenRuntimeOption, TokenTarget}, tokenRoles(tokens))
+	for _, token := range tokens {
+		assert.False(t, token.Unknown, token.Token)
+	}
+
+	// the windows service hosts
+	_, tokens, err = ParseExplained(true, `C:\Windows\system32\svchost.exe -k netsvcs -p -s Schedule`)
+	assert.NoError(t, err)
+	assert.Equal(t, []TokenRole{TokenExecutable, TokenWrapperOption, TokenOptionValue, TokenWrapperOption, TokenWrapperOption, TokenTarget}, tokenRoles(tokens))
+	assert.Equal(t, "-s", tokens[5].Option)
+	_, tokens, err = ParseExplained(true, `svchost.exe -k LocalService /x`)
+	assert.NoError(t, err)
+	assert.Equal(t, []TokenRole{TokenExecutable, TokenWrapperOption, TokenTarget, TokenWrapperOption}, tokenRoles(tokens))
+	assert.True(t, tokens[3].Unknown)
+	_, tokens, err = ParseExplained(true, `rundll32.exe shell32.dll,Control_RunDLL desk.cpl`)
+	assert.NoError(t, err)
+	assert.Equal(t, []TokenRole{TokenExecutable, TokenTarget, TokenArgument}, tokenRoles(tokens))
+	_, tokens, err = ParseExplained(true, `msiexec.exe /qn /i C:\setup\agent.msi`)
+	assert.NoError(t, err)
+	assert.Equal(t, []TokenRole{TokenExecutable, TokenWrapperOption, TokenWrapperOption, TokenTarget}, tokenRoles(tokens))
+	_, tokens, err = ParseExplained(true, `dllhost.exe /Processid:{AB8902B4-09CA-4BB6-B78D-A8F59079A8D5}`)
+	assert.NoError(t, err)
+	assert.Equal(t, []TokenRole{TokenExecutable, TokenTarget}, tokenRoles(tokens))
+
+	c, tokens, err = ParseExplained(false, "")
+	assert.NoError(t, err)
+	assert.NotNil(t, c)
+	assert.Empty(t, tokens)
+
+	assert.Equal(t, "main target", TokenTarget.String())
+	assert.Equal(t, "TokenRole(42)", TokenRole(42).String())
+}
+
+func tokenRoles(tokens []TokenAnnotation) []TokenRole {
+	roles := make([]TokenRole, 0, len(tokens))
+	for _, t := range tokens {
+		roles = append(roles, t.Role)
+	}
+	return roles
+}
@@ -0,0 +1,354 @@
+package cmdline
+
+import (
+	"errors"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// What a file is used for
+const (
+	FileConfig      = "config"
+	FileLog         = "log"
+	FilePID         = "pid"
+	FileData        = "data"
+	FileKeystore    = "keystore"
+	FileCertificate = "certificate"
+	FileKey         = "key"
+)
+
+// FileRef is a file or directory a process is told to use
+type FileRef struct {
+	Kind string
+	// Path is the path as found on the command line
+	Path string
+	// Resolved is Path made absolute with WorkingDir, empty when Path is
+	// relative and WorkingDir is unknown
+	Resolved string
+
+	// Index is the index in Args of the argument with the path
+	Index int
+}
+
+type fileValue int
+
+const (
+	// a path, "file:" urls are accepted
+	valueFilePath fileValue = iota
+	// paths separated by ','
+	valueFileList
+	// the options of -Xlog, with file=path
+	valueFileXlog
+	// the first argument of the program that isn't an option
+	valueFilePositional
+)
+
+type fileRule struct {
+	// Flag is matched as "flag value" and "flag=value"
+	Flag string
+	// Prefix matches the arguments that start with Flag, the rest is the value
+	Prefix bool
+	// Attached flags take their value only with '=' or as Prefix
+	Attached bool
+	Value    fileValue
+	Kind     string
+}
+
+// the rules of any program, they only see the arguments of the program and
+// not the ones of its runtime, python -c is no config file
+var genericFileRules = []fileRule{
+	{Flag: "-c", Kind: FileConfig},
+	{Flag: "--config", Kind: FileConfig},
+	{Flag: "--conf", Kind: FileConfig},
+	{Flag: "--config-file", Kind: FileConfig},
+	{Flag: "--configfile", Kind: FileConfig},
+	{Flag: "--config.file", Kind: FileConfig},
+	{Flag: "--spring.config.location", Value: valueFileList, Kind: FileConfig},
+	{Flag: "--log-file", Kind: FileLog},
+	{Flag: "--logfile", Kind: FileLog},
+	{Flag: "--log-path", Kind: FileLog},
+	{Flag: "--log-dir", Kind: FileLog},
+	{Flag: "--error-log", Kind: FileLog},
+	{Flag: "--access-logfile", Kind: FileLog},
+	{Flag: "--error-logfile", Kind: FileLog},
+	{Flag: "--pidfile", Kind: FilePID},
+	{Flag: "--pid-file", Kind: FilePID},
+	{Flag: "--pid", Kind: FilePID},
+	{Flag: "--data-dir", Kind: FileData},
+	{Flag: "--datadir", Kind: FileData},
+	{Flag: "--data-path", Kind: FileData},
+	{Flag: "--storage.tsdb.path", Kind: FileData},
+	{Flag: "--cert", Kind: FileCertificate},
+	{Flag: "--cert-file", Kind: FileCertificate},
+	{Flag: "--certfile", Kind: FileCertificate},
+	{Flag: "--tls-cert", Kind: FileCertificate},
+	{Flag: "--tls-cert-file", Kind: FileCertificate},
+	{Flag: "--ssl-cert", Kind: FileCertificate},
+	{Flag: "--cacert", Kind: FileCertificate},
+	{Flag: "--ca-file", Kind: FileCertificate},
+	{Flag: "--cafile", Kind: FileCertificate},
+	{Flag: "--tls-ca", Kind: FileCertificate},
+	{Flag: "--key", Kind: FileKey},
+	{Flag: "--key-file", Kind: FileKey},
+	{Flag: "--keyfile", Kind: FileKey},
+	{Flag: "--tls-key", Kind: FileKey},
+	{Flag: "--tls-key-file", Kind: FileKey},
+	{Flag: "--ssl-key", Kind: FileKey},
+	{Flag: "--keystore", Kind: FileKeystore},
+	{Flag: "--truststore", Kind: FileKeystore},
+}
+
+// the rules of the runtimes, they see the options of the runtime
+var runtimeFileRules = map[string][]fileRule{
+	"java": {
+		{Flag: "-Dlog4j.configurationFile", Attached: true, Value: valueFileList, Kind: FileConfig},
+		{Flag: "-Dlog4j.configuration", Attached: true, Kind: FileConfig},
+		{Flag: "-Dlog4j2.configurationFile", Attached: true, Value: valueFileList, Kind: FileConfig},
+		{Flag: "-Dlogback.configurationFile", Attached: true, Kind: FileConfig},
+		{Flag: "-Djava.util.logging.config.file", Attached: true, Kind: FileConfig},
+		{Flag: "-Dspring.config.location", Attached: true, Value: valueFileList, Kind: FileConfig},
+		{Flag: "-Djava.security.policy", Attached: true, Kind: FileConfig},
+		{Flag: "-Djava.security.auth.login.config", Attached: true, Kind: FileConfig},
+		{Flag: "-Djavax.net.ssl.trustStore", Attached: true, Kind: FileKeystore},
+		{Flag: "-Djavax.net.ssl.keyStore", Attached: true, Kind: FileKeystore},
+		{Flag: "-Dkafka.logs.dir", Attached: true, Kind: FileLog},
+		{Flag: "-Dlog.dir", Attached: true, Kind: FileLog},
+		{Flag: "-Djava.io.tmpdir", Attached: true, Kind: FileData},
+		{Flag: "-Xloggc:", Prefix: true, Kind: FileLog},
+		{Flag: "-Xlog:", Prefix: true, Value: valueFileXlog, Kind: FileLog},
+		{Flag: "-XX:HeapDumpPath=", Prefix: true, Kind: FileData},
+		{Flag: "-XX:ErrorFile=", Prefix: true, Kind: FileLog},
+	},
+}
+
+// the rules of the products, keyed like productEndpointRules. They come
+// before the generic rules and see the arguments of the program.
+var productFileRules = map[string][]fileRule{
+	"nginx": {
+		{Flag: "-p", Kind: FileData},
+	},
+	"redis-server": {
+		{Value: valueFilePositional, Kind: FileConfig},
+	},
+	"redis-sentinel": {
+		{Value: valueFilePositional, Kind: FileConfig},
+	},
+	"haproxy": {
+		{Flag: "-f", Kind: FileConfig},
+		{Flag: "-p", Kind: FilePID},
+	},
+	"httpd": {
+		{Flag: "-f", Kind: FileConfig},
+		{Flag: "-d", Kind: FileData},
+	},
+	"apache2": {
+		{Flag: "-f", Kind: FileConfig},
+		{Flag: "-d", Kind: FileData},
+	},
+	"postgres": {
+		{Flag: "-D", Kind: FileData},
+	},
+	"mysqld": {
+		{Flag: "--defaults-file", Kind: FileConfig},
+		{Flag: "--log-error", Kind: FileLog},
+	},
+	"mongod": {
+		{Flag: "-f", Kind: FileConfig},
+		{Flag: "--dbpath", Kind: FileData},
+		{Flag: "--logpath", Kind: FileLog},
+		{Flag: "--pidfilepath", Kind: FilePID},
+	},
+}
+
+// the shells run their -c as a command
+var fileRuleShells = []string{"sh", "bash", "dash", "zsh", "ksh", "ash", "fish"}
+
+// Files collects the config files, log files, pid files, data directories,
+// keystores and certificates the command line tells the process to use.
+// Relative paths are resolved with WorkingDir when it is known.
+func (c *CommandLine) Files() []FileRef {
+	var files []FileRef
+	add := func(index int, rule *fileRule, value string) bool {
+		found := false
+		for _, p := range fileValues(rule, value) {
+			files = append(files, FileRef{Kind: rule.Kind, Path: p, Resolved: c.resolvePath(p), Index: index})
+			found = true
+		}
+		return found
+	}
+
+	// the runtime options
+	start := c.programArgsStart()
+	scanFileRules(c.Args[:start], 0, runtimeFileRules[c.runtime()], add)
+
+	var rules []fileRule
+	for _, name := range c.endpointProducts() {
+		rules = append(rules, productFileRules[name]...)
+	}
+	if !contains(fileRuleShells, strings.ToLower(executableName(c.isWindows(), c.programName()))) {
+		rules = append(rules, genericFileRules...)
+	}
+	scanFileRules(c.Args[start:], start, rules, add)
+
+	// the roles of a rule file, unless a rule above found the argument
+	if c.Custom != nil {
+	values:
+		for _, v := range c.Custom.Values {
+			switch v.Role {
+			case FileConfig, FileLog, FilePID, FileData, FileKeystore, FileCertificate, FileKey:
+			default:
+				continue
+			}
+			for _, f := range files {
+				if f.Index == v.Index {
+					continue values
+				}
+			}
+			add(v.Index, &fileRule{Kind: v.Role}, v.Value)
+		}
+	}
+	return files
+}
+
+func scanFileRules(args []string, offset int, rules []fileRule, add func(index int, rule *fileRule, value string) bool) {
+	for ridx := range rules {
+		rule := &rules[ridx]
+		if rule.Value != valueFilePositional {
+			continue
+		}
+		for idx, a := range args {
+			if !strings.HasPrefix(a, "-") {
+				// the process title of redis is "redis-server *:6379"
+				if _, ok := parseAddress(a); !ok {
+					add(offset+idx, rule, a)
+				}
+				break
+			}
+		}
+	}
+
+	for idx := 0; idx < len(args); idx++ {
+		a := args[idx]
+		for ridx := range rules {
+			rule := &rules[ridx]
+			if rule.Value == valueFilePositional {
+				continue
+			}
+			if rule.Prefix {
+				if strings.HasPrefix(a, rule.Flag) && add(offset+idx, rule, a[len(rule.Flag):]) {
+					break
+				}
+				continue
+			}
+
+			if value, ok := strings.CutPrefix(a, rule.Flag+"="); ok {
+				if add(offset+idx, rule, value) {
+					break
+				}
+				continue
+			}
+			if a == rule.Flag && !rule.Attached && idx+1 < len(args) && add(offset+idx+1, rule, args[idx+1]) {
+				idx++
+				break
+			}
+		}
+	}
+}
+
+func fileValues(rule *fileRule, value string) []string {
+	var values []string
+	switch rule.Value {
+	case valueFileList:
+		values = strings.Split(value, ",")
+	case valueFileXlog:
+		// -Xlog:gc*:file=/var/log/gc.log:time
+		for _, part := range strings.Split(value, ":") {
+			if p, ok := strings.CutPrefix(part, "file="); ok {
+				values = append(values, strings.Trim(p, "\""))
+			}
+		}
+	default:
+		values = []string{value}
+	}
+
+	paths := values[:0]
+	for _, v := range values {
+		v = strings.TrimSpace(v)
+		if strings.HasPrefix(v, "classpath:") || strings.HasPrefix(v, "optional:classpath:") {
+			continue
+		}
+		v = strings.TrimPrefix(v, "optional:")
+		if p, ok := strings.CutPrefix(v, "file://"); ok {
+			v = p
+		} else {
+			v = strings.TrimPrefix(v, "file:")
+		}
+		// a number is a pid or a count, not a path
+		if _, err := strconv.Atoi(v); v == "" || strings.HasPrefix(v, "-") || err == nil {
+			continue
+		}
+		paths = append(paths, v)
+	}
+	return paths
+}
+
+// programArgsStart is the index in Args of the first argument of the
+// program, after the options of its runtime or wrapper
+func (c *CommandLine) programArgsStart() int {
+	var rest []string
+	switch {
+	case c.Java != nil:
+		rest = c.Java.Args
+	case c.Python != nil:
+		rest = c.Python.Args
+	case c.Ruby != nil:
+		rest = c.Ruby.Args
+	case c.Sub != nil:
+		rest = c.Sub.Args
+	case c.hasDiagnostic(ErrNoScript) || c.hasDiagnostic(ErrNoMainClass):
+		// a runtime without program, like python -c
+		return len(c.Args)
+	default:
+		return 0
+	}
+	return len(c.Args) - len(rest)
+}
+
+// hasDiagnostic reports whether a diagnostic of the command line is target
+func (c *CommandLine) hasDiagnostic(target error) bool {
+	for _, d := range c.Diagnostics {
+		if errors.Is(d.Err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// programName is the executable of the arguments after programArgsStart
+func (c *CommandLine) programName() string {
+	if c.Sub != nil {
+		return c.Sub.Command
+	}
+	return c.ExecutePath
+}
+
+func (c *CommandLine) resolvePath(p string) string {
+	if c.isWindows() {
+		if len(p) >= 3 && p[1] == ':' && (p[2] == '\\' || p[2] == '/') || strings.HasPrefix(p, `\\`) {
+			return p
+		}
+		if c.WorkingDir == "" {
+			return ""
+		}
+		return strings.TrimRight(c.WorkingDir, `\/`) + `\` + p
+	}
+
+	if path.IsAbs(p) {
+		return path.Clean(p)
+	}
+	if c.WorkingDir == "" {
+		return ""
+	}
+	return path.Join(c.WorkingDir, p)
+}
@@ -0,0 +1,121 @@
+package cmdline
+
+import (
+	"errors"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFiles(t *testing.T) {
+	tests := []struct {
+		isWindows  bool
+		cmdline    string
+		workingDir string
+		expected   []FileRef
+	}{
+		{
+			cmdline: "nginx -c /etc/nginx/nginx.conf -p /var/lib/nginx -g 'daemon off;'",
+			expected: []FileRef{
+				{Kind: FileConfig, Path: "/etc/nginx/nginx.conf", Resolved: "/etc/nginx/nginx.conf", Index: 1},
+				{Kind: FileData, Path: "/var/lib/nginx", Resolved: "/var/lib/nginx", Index: 3},
+			},
+		},
+		{
+			cmdline:    "redis-server redis.conf --port 6380 --pidfile=/run/redis.pid --logfile ../log/redis.log",
+			workingDir: "/opt/redis/etc",
+			expected: []FileRef{
+				{Kind: FileConfig, Path: "redis.conf", Resolved: "/opt/redis/etc/redis.conf", Index: 0},
+				{Kind: FilePID, Path: "/run/redis.pid", Resolved: "/run/redis.pid", Index: 3},
+				{Kind: FileLog, Path: "../log/redis.log", Resolved: "/opt/redis/log/redis.log", Index: 5},
+			},
+		},
+		{
+			cmdline:  "redis-server *:6379",
+			expected: nil,
+		},
+		{
+			cmdline: "java -Dlog4j.configurationFile=conf/log4j2.xml -Djavax.net.ssl.trustStore=/etc/ssl/trust.jks " +
+				"-Dspring.config.location=classpath:/app.yml,file:/etc/app/app.yml -Xlog:gc*:file=/var/log/gc.log:time " +
+				"-XX:HeapDumpPath=/var/dumps -jar app.jar --config /etc/app/extra.yml -c 3",
+			expected: []FileRef{
+				{Kind: FileConfig, Path: "conf/log4j2.xml", Index: 0},
+				{Kind: FileKeystore, Path: "/etc/ssl/trust.jks", Resolved: "/etc/ssl/trust.jks", Index: 1},
+				{Kind: FileConfig, Path: "/etc/app/app.yml", Resolved: "/etc/app/app.yml", Index: 2},
+				{Kind: FileLog, Path: "/var/log/gc.log", Resolved: "/var/log/gc.log", Index: 3},
+				{Kind: FileData, Path: "/var/dumps", Resolved: "/var/dumps", Index: 4},
+				{Kind: FileConfig, Path: "/etc/app/extra.yml", Resolved: "/etc/app/extra.yml", Index: 8},
+			},
+		},
+		{
+			// -c of python is code, not a config file
+			cmdline:  "python3 -c 'import app'",
+			expected: nil,
+		},
+		{
+			cmdline:    "python3 -m gunicorn -c gunicorn.conf.py --access-logfile - --certfile=tls/cert.pem --keyfile tls/key.pem app:app",
+			workingDir: "/srv/app",
+			expected: []FileRef{
+				{Kind: FileConfig, Path: "gunicorn.conf.py", Resolved: "/srv/app/gunicorn.conf.py", Index: 3},
+				{Kind: FileCertificate, Path: "tls/cert.pem", Resolved: "/srv/app/tls/cert.pem", Index: 6},
+				{Kind: FileKey, Path: "tls/key.pem", Resolved: "/srv/app/tls/key.pem", Index: 8},
+			},
+		},
+		{
+			cmdline:  "bash -c 'exec /usr/bin/app'",
+			expected: nil,
+		},
+		{
+			cmdline: "sudo -u postgres postgres -D /var/lib/postgresql/data --pid 42",
+			expected: []FileRef{
+				{Kind: FileData, Path: "/var/lib/postgresql/data", Resolved: "/var/lib/postgresql/data", Index: 4},
+			},
+		},
+		{
+			isWindows:  true,
+			cmdline:    `C:\nginx\nginx.exe -c conf\nginx.conf --log-file D:\logs\nginx.log`,
+			workingDir: `C:\nginx\`,
+			expected: []FileRef{
+				{Kind: FileConfig, Path: `conf\nginx.conf`, Resolved: `C:\nginx\conf\nginx.conf`, Index: 1},
+				{Kind: FileLog, Path: `D:\logs\nginx.log`, Resolved: `D:\logs\nginx.log`, Index: 3},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		flavor := FlavorPOSIX
+		if tt.isWindows {
+			flavor = FlavorWindows
+		}
+		c, err := NewParser(WithFlavor(flavor), WithWorkingDir(tt.workingDir), WithStrictness(StrictnessLenient)).ParseCommandLine(tt.cmdline)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		assert.Equal(t, tt.expected, c.Files(), tt.cmdline)
+	}
+}
+
+func TestFilesWithDiagnostics(t *testing.T) {
+	fsys := linkFS{fstest.MapFS{
+		"usr/bin/loop":  link("loop2"),
+		"usr/bin/loop2": link("./loop"),
+	}}
+
+	// a link loop leaves the program known, its arguments are still scanned
+	c, err := NewParser(WithFS(fsys)).ParseCommandLine("/usr/bin/loop --config /etc/app.yml")
+	assert.NoError(t, err)
+	if assert.Len(t, c.Diagnostics, 1) {
+		assert.True(t, errors.Is(c.Diagnostics[0].Err, ErrLinkLoop))
+	}
+	assert.Equal(t, []FileRef{{Kind: FileConfig, Path: "/etc/app.yml", Resolved: "/etc/app.yml", Index: 1}}, c.Files())
+
+	// a python without script has no program arguments
+	c, err = NewParser(WithStrictness(StrictnessLenient)).ParseCommandLine("python3 -c 'import app' --config /etc/app.yml")
+	assert.NoError(t, err)
+	if assert.Len(t, c.Diagnostics, 1) {
+		assert.True(t, errors.Is(c.Diagnostics[0].Err, ErrNoScript))
+	}
+	assert.Nil(t, c.Files())
+}
@@ -0,0 +1,220 @@
+package cmdline
+
+import (
+	"sort"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/mattn/go-shellwords"
+)
+
+func FuzzParseCommandLine(f *testing.F) {
+	for _, tt := range serviceMetadataTests {
+		f.Add(tt.isWindows, tt.cmdline)
+	}
+	f.Fuzz(func(t *testing.T, isWindows bool, s string) {
+		c, err := NewParser(WithFlavor(flavorOf(isWindows)), WithMaxDepth(2)).ParseCommandLine(s)
+		if c == nil {
+			if err == nil {
+				t.Fatalf("%q: no command line and no error", s)
+			}
+			return
+		}
+		checkCommandLine(t, s, c)
+
+		// every token is explained once, in order
+		_, explained, _ := NewParser(WithFlavor(flavorOf(isWindows)), WithMaxDepth(2)).ParseExplained(s)
+		expected := append(append([]string{}, c.Env...), c.Argv()...)
+		if len(explained) != len(expected) {
+			t.Fatalf("%q: %d tokens explained, want %q", s, len(explained), expected)
+		}
+		for idx, a := range explained {
+			if a.Token != expected[idx] {
+				t.Fatalf("%q: token %d is %q, want %q", s, idx, a.Token, expected[idx])
+			}
+		}
+
+		var tokens []string
+		if isWindows {
+			tokens, err = SplitWindows(strings.TrimLeft(s, " \t"))
+		} else {
+			tokens, err = shellwords.NewParser().Parse(s)
+		}
+		if err != nil || !isTail(c.Args, tokens) {
+			t.Fatalf("%q: args %q aren't a tail of %q: %v", s, c.Args, tokens, err)
+		}
+
+		// the quoted command line parses to the same arguments
+		argv := c.Argv()
+		if len(argv) == 0 || strings.Contains(argv[0], "\"") || strings.Count(argv[0], "=") == 1 {
+			return
+		}
+		style := QuotePOSIX
+		if isWindows {
+			style = QuoteCmd
+		} else if !utf8.ValidString(s) {
+			return
+		}
+		quoted := c.String(style)
+		again, err := ParseCommandLine(isWindows, quoted)
+		if again == nil {
+			t.Fatalf("%q: %q doesn't parse: %v", s, quoted, err)
+		}
+		if got := again.Argv(); !equalStrings(argv, got) {
+			t.Fatalf("%q: %q parses to %q, want %q", s, quoted, got, argv)
+		}
+	})
+}
+
+func FuzzParse(f *testing.F) {
+	for _, tt := range serviceMetadataTests {
+		f.Add(tt.isWindows, strings.Join(strings.Fields(tt.cmdline), "\x00"))
+	}
+	f.Fuzz(func(t *testing.T, isWindows bool, s string) {
+		argv := strings.Split(s, "\x00")
+		c, err := NewParser(WithFlavor(flavorOf(isWindows)), WithMaxDepth(2)).Parse(argv[0], argv[1:])
+		if c == nil {
+			t.Fatalf("%q: no command line: %v", argv, err)
+		}
+		if c.ExecutePath != argv[0] || !equalStrings(c.Args, argv[1:]) {
+			t.Fatalf("%q: argv changed to %q", argv, c.Argv())
+		}
+		checkCommandLine(t, s, c)
+	})
+}
+
+func FuzzSplitVersion(f *testing.F) {
+	for _, s := range []string{"", "python2.7", "php-fpm8.2", "1.2", "node18", "ünïcode3"} {
+		f.Add(s)
+	}
+	f.Fuzz(func(t *testing.T, s string) {
+		base, version := splitVersion(s)
+		if base+version != s {
+			t.Fatalf("%q: split into %q and %q", s, base, version)
+		}
+		if strings.Trim(version, "0123456789.") != "" {
+			t.Fatalf("%q: version %q", s, version)
+		}
+		if base != "" && strings.ContainsAny(base[len(base)-1:], "0123456789.") {
+			t.Fatalf("%q: base %q", s, base)
+		}
+	})
+}
+
+// FuzzExtractors runs every built-in extractor on the arguments
+func FuzzExtractors(f *testing.F) {
+	for _, tt := range serviceMetadataTests {
+		if fields := strings.Fields(tt.cmdline); len(fields) > 0 {
+			f.Add(strings.Join(fields[1:], "\x00"))
+		}
+	}
+
+	var names, windowsNames []string
+	for name := range defaultRegistry.extractors {
+		names = append(names, name)
+	}
+	for name := range defaultRegistry.windowsExtractors {
+		windowsNames = append(windowsNames, name)
+	}
+	sort.Strings(names)
+	sort.Strings(windowsNames)
+
+	f.Fuzz(func(t *testing.T, s string) {
+		args := strings.Split(s, "\x00")
+		run := func(isWindows bool, name string, fn ExtractorFunc) {
+			c := &CommandLine{ExecutePath: name, Args: append([]string{}, args...)}
+			fn(c)
+			if !equalStrings(c.Args, args) {
+				t.Fatalf("%s %q: args changed to %q", name, args, c.Args)
+			}
+			checkCommandLine(t, s, c)
+		}
+		for _, name := range names {
+			run(false, name, defaultRegistry.extractors[name])
+		}
+		for _, name := range windowsNames {
+			run(true, name, defaultRegistry.windowsExtractors[name])
+		}
+	})
+}
+
+func flavorOf(isWindows bool) Flavor {
+	if isWindows {
+		return FlavorWindows
+	}
+	return FlavorPOSIX
+}
+
+// checkCommandLine checks that the arguments of the runtimes and wrapped
+// commands are the tails of Args, and that the accessors don't panic
+func checkCommandLine(t *testing.T, s string, c *CommandLine) {
+	t.Helper()
+
+	tails := map[string][]string{}
+	if c.Sub != nil {
+		tails["sub"] = c.Sub.Args
+		if c.Sub.CommandLine != nil {
+			if c.Sub.CommandLine.ExecutePath != c.Sub.Command || !equalStrings(c.Sub.CommandLine.Args, c.Sub.Args) {
+				t.Fatalf("%q: sub command line %q", s, c.Sub.CommandLine.Argv())
+			}
+			checkCommandLine(t, s, c.Sub.CommandLine)
+		}
+	}
+	if c.Ruby != nil {
+		tails["ruby"] = c.Ruby.Args
+	}
+	if c.Python != nil {
+		tails["python"] = c.Python.Args
+	}
+	if c.Java != nil {
+		tails["java"] = c.Java.Args
+	}
+	for name, tail := range tails {
+		if !isTail(tail, c.Args) {
+			t.Fatalf("%q: %s args %q aren't a tail of %q", s, name, tail, c.Args)
+		}
+	}
+	// the windows hosts leave out the options they know
+	if c.Windows != nil && !isSubsequence(c.Windows.Args, c.Args) {
+		t.Fatalf("%q: windows args %q aren't in %q", s, c.Windows.Args, c.Args)
+	}
+	for _, d := range c.Diagnostics {
+		if d.Index < -1 || d.Index >= len(c.Args) || d.Err == nil {
+			t.Fatalf("%q: diagnostic %v", s, d)
+		}
+	}
+
+	c.ServiceName()
+	c.Endpoints()
+	c.Files()
+	c.Redact(DefaultRedactionRules)
+	if _, err := c.MarshalJSON(); err != nil {
+		t.Fatalf("%q: %v", s, err)
+	}
+}
+
+func isTail(tail, s []string) bool {
+	return len(tail) <= len(s) && equalStrings(tail, s[len(s)-len(tail):])
+}
+
+func isSubsequence(sub, s []string) bool {
+	for _, a := range s {
+		if len(sub) > 0 && sub[0] == a {
+			sub = sub[1:]
+		}
+	}
+	return len(sub) == 0
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for idx := range a {
+		if a[idx] != b[idx] {
+			return false
+		}
+	}
+	return true
+}
@@ -1,16 +1,16 @@
 module github.com/mei-rune/cmdline
 
-go 1.20
+go 1.21
 
 require (
 	github.com/mattn/go-shellwords v1.0.12
 	github.com/stretchr/testify v1.8.4
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
 
 replace github.com/mattn/go-shellwords => gitee.com/runner.mei/go-shellwords v1.0.13-0.20231225050914-b3be3c4550a5
@@ -0,0 +1,259 @@
+package cmdline
+
+import (
+	"strings"
+)
+
+// OptionStyle is how a binary writes its options
+type OptionStyle int
+
+const (
+	// StyleGNU has short options that combine, "-xvf", with attached values,
+	// "-n5", and long options with "--name=value" or "--name value". A
+	// declared name like "-config" is matched as a whole before it is taken
+	// apart, and "-n=5" is accepted for the programs of Go's flag package.
+	StyleGNU OptionStyle = iota
+	// StylePOSIX is StyleGNU where the first argument that isn't an option
+	// always ends the options
+	StylePOSIX
+	// StyleJava has single dash long options, "-cp path", and the prefix
+	// options like "-Dname=value" or "-Xmx1g", they never combine
+	StyleJava
+	// StyleWindows has options starting with '/' or '-', "/opt:value" or
+	// "/opt value", the names are case insensitive
+	StyleWindows
+)
+
+// ValueKind tells if an option takes a value
+type ValueKind int
+
+const (
+	// NoValue is a boolean flag
+	NoValue ValueKind = iota
+	// RequiredValue is attached, "-n5", "--n=5" or "/n:5", or else the
+	// next argument
+	RequiredValue
+	// AttachedValue is only attached, like "--color=auto" or ruby -W0, the
+	// option is valid alone too
+	AttachedValue
+)
+
+// OptionSpec declares an option of a binary
+type OptionSpec struct {
+	// Names are the spellings with their dashes or slash, e.g. "-u" and
+	// "--user", the first one names the option
+	Names []string
+	Value ValueKind
+	// Prefix options match the arguments that start with the name, the
+	// rest is the value, like "-D" or "-javaagent:"
+	Prefix bool
+	// Terminal options end the options, like python -m or java -jar
+	Terminal bool
+}
+
+// OptionGrammar declares the options of a binary
+type OptionGrammar struct {
+	Style   OptionStyle
+	Options []OptionSpec
+	// Interspersed lets options follow the arguments that aren't options,
+	// else the first one ends the options
+	Interspersed bool
+	// MaxPositionals ends the options after that many arguments that
+	// aren't options with Interspersed, 0 is no limit
+	MaxPositionals int
+}
+
+// ParsedOption is an option found by ParseOptions
+type ParsedOption struct {
+	// Spec is the declared option, nil for an unknown one
+	Spec *OptionSpec
+	// Name is the option as written, without its value
+	Name     string
+	Value    string
+	HasValue bool
+	// Index is the index in args of the option, ValueIndex the one of its
+	// value, the same for an attached value
+	Index      int
+	ValueIndex int
+}
+
+// ParsedOptions is what ParseOptions found in the arguments
+type ParsedOptions struct {
+	Options []ParsedOption
+	// Positionals are the indexes of the arguments that aren't options nor
+	// values, they are only collected with Interspersed or after "--"
+	Positionals []int
+	// End is the index of the first argument after the options: the first
+	// argument that isn't an option, the one after "--" or after a
+	// Terminal option and its value, or len(args)
+	End int
+}
+
+// Terminal returns the Terminal option that ended the options, or nil
+func (p *ParsedOptions) Terminal() *ParsedOption {
+	if len(p.Options) == 0 {
+		return nil
+	}
+	last := &p.Options[len(p.Options)-1]
+	if last.Spec == nil || !last.Spec.Terminal {
+		return nil
+	}
+	return last
+}
+
+// Lookup returns the last value of the option name, a name of its Spec
+func (p *ParsedOptions) Lookup(name string) (*ParsedOption, bool) {
+	for idx := len(p.Options) - 1; idx >= 0; idx-- {
+		if o := &p.Options[idx]; o.Spec != nil && contains(o.Spec.Names, name) {
+			return o, true
+		}
+	}
+	return nil, false
+}
+
+// ParseOptions reads the options of args with the grammar, unknown options
+// are taken as boolean flags
+func (g *OptionGrammar) ParseOptions(args []string) *ParsedOptions {
+	// small enough to be inlined, p and the first options stay on the
+	// stack of the extractors
+	var options [8]ParsedOption
+	p := g.parseOptions(options[:0], args)
+	return &p
+}
+
+// parseOptions works on its own ParsedOptions, the stores through a pointer
+// would move the options of ParseOptions to the heap
+func (g *OptionGrammar) parseOptions(options []ParsedOption, args []string) ParsedOptions {
+	p := ParsedOptions{Options: options, End: len(args)}
+	interspersed := g.Interspersed && g.Style != StylePOSIX
+	optionsEnd := false
+
+	for idx := 0; idx < len(args); idx++ {
+		a := args[idx]
+		if optionsEnd || !g.isOption(a) {
+			if !interspersed {
+				p.End = idx
+				return p
+			}
+			p.Positionals = append(p.Positionals, idx)
+			if g.MaxPositionals > 0 && len(p.Positionals) >= g.MaxPositionals {
+				p.End = idx + 1
+				return p
+			}
+			continue
+		}
+		if a == "--" && g.Style != StyleWindows {
+			if !interspersed {
+				p.End = idx + 1
+				return p
+			}
+			optionsEnd = true
+			continue
+		}
+
+		start := len(p.Options)
+		p.Options, idx = g.parseOption(p.Options, args, idx)
+		for _, o := range p.Options[start:] {
+			if o.Spec != nil && o.Spec.Terminal {
+				p.End = idx + 1
+				return p
+			}
+		}
+	}
+	return p
+}
+
+func (g *OptionGrammar) isOption(a string) bool {
+	if len(a) < 2 {
+		return false
+	}
+	if g.Style == StyleWindows {
+		return a[0] == '/' || a[0] == '-'
+	}
+	return a[0] == '-'
+}
+
+// parseOption adds the options of args[idx] to options and returns the
+// index of the last argument it used
+func (g *OptionGrammar) parseOption(options []ParsedOption, args []string, idx int) ([]ParsedOption, int) {
+	a := args[idx]
+	add := func(spec *OptionSpec, name string, value string, hasValue bool) ([]ParsedOption, int) {
+		o := ParsedOption{Spec: spec, Name: name, Value: value, HasValue: hasValue, Index: idx, ValueIndex: idx}
+		if spec != nil && spec.Value == RequiredValue && !hasValue && idx+1 < len(args) {
+			idx++
+			o.Value, o.HasValue, o.ValueIndex = args[idx], true, idx
+		}
+		options = append(options, o)
+		return options, idx
+	}
+
+	if spec, prefix := g.lookupPrefix(a); spec != nil {
+		return add(spec, prefix, a[len(prefix):], true)
+	}
+
+	sep := "="
+	if g.Style == StyleWindows {
+		sep = ":="
+	}
+	name, value, hasValue := a, "", false
+	if i := strings.IndexAny(a, sep); i > 0 {
+		name, value, hasValue = a[:i], a[i+1:], true
+	}
+	if spec := g.lookup(name); spec != nil {
+		return add(spec, name, value, hasValue)
+	}
+
+	if g.Style == StyleJava || g.Style == StyleWindows || strings.HasPrefix(a, "--") {
+		return add(nil, name, value, hasValue)
+	}
+
+	// a cluster of short options like "-xvf" or "-n5"
+	if g.lookup(a[:2]) == nil {
+		return add(nil, name, value, hasValue)
+	}
+	for i := 1; i < len(a); i++ {
+		spec := g.lookup("-" + a[i:i+1])
+		if spec == nil || spec.Value == NoValue {
+			options, _ = add(spec, "-"+a[i:i+1], "", false)
+			continue
+		}
+		if rest := strings.TrimPrefix(a[i+1:], "="); rest != "" {
+			return add(spec, "-"+a[i:i+1], rest, true)
+		}
+		return add(spec, "-"+a[i:i+1], "", false)
+	}
+	return options, idx
+}
+
+func (g *OptionGrammar) lookup(name string) *OptionSpec {
+	for idx := range g.Options {
+		spec := &g.Options[idx]
+		if spec.Prefix {
+			continue
+		}
+		for _, n := range spec.Names {
+			if n == name {
+				return spec
+			}
+			if g.Style == StyleWindows && len(n) > 1 && len(name) > 1 && strings.EqualFold(n[1:], name[1:]) {
+				return spec
+			}
+		}
+	}
+	return nil
+}
+
+func (g *OptionGrammar) lookupPrefix(a string) (*OptionSpec, string) {
+	for idx := range g.Options {
+		spec := &g.Options[idx]
+		if !spec.Prefix {
+			continue
+		}
+		for _, n := range spec.Names {
+			if strings.HasPrefix(a, n) {
+				return spec, n
+			}
+		}
+	}
+	return nil, ""
+}
@@ -0,0 +1,261 @@
+package cmdline
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseOptions(t *testing.T) {
+	gnu := &OptionGrammar{
+		Style: StyleGNU,
+		Options: []OptionSpec{
+			{Names: []string{"-n", "--lines"}, Value: RequiredValue},
+			{Names: []string{"-x"}},
+			{Names: []string{"-v", "--verbose"}},
+			{Names: []string{"-f"}, Value: RequiredValue},
+			{Names: []string{"--color"}, Value: AttachedValue},
+			{Names: []string{"-config"}, Value: RequiredValue},
+		},
+	}
+	interspersed := *gnu
+	interspersed.Interspersed = true
+	limited := interspersed
+	limited.MaxPositionals = 1
+	posix := interspersed
+	posix.Style = StylePOSIX
+
+	java := &OptionGrammar{
+		Style: StyleJava,
+		Options: []OptionSpec{
+			{Names: []string{"-cp", "--class-path"}, Value: RequiredValue},
+			{Names: []string{"-jar"}, Value: RequiredValue, Terminal: true},
+			{Names: []string{"-D"}, Prefix: true},
+		},
+	}
+	windows := &OptionGrammar{
+		Style: StyleWindows,
+		Options: []OptionSpec{
+			{Names: []string{"/k"}, Value: RequiredValue},
+			{Names: []string{"/processid"}, Value: RequiredValue},
+			{Names: []string{"/p"}},
+		},
+	}
+
+	gnuSpec := func(idx int) *OptionSpec { return &gnu.Options[idx] }
+	tests := []struct {
+		grammar  *OptionGrammar
+		args     string
+		expected *ParsedOptions
+	}{
+		{
+			grammar: gnu,
+			args:    "-xvf archive.tar -n5 --lines=10 --lines 20 file",
+			expected: &ParsedOptions{
+				Options: []ParsedOption{
+					{Spec: gnuSpec(1), Name: "-x", Index: 0, ValueIndex: 0},
+					{Spec: gnuSpec(2), Name: "-v", Index: 0, ValueIndex: 0},
+					{Spec: gnuSpec(3), Name: "-f", Value: "archive.tar", HasValue: true, Index: 0, ValueIndex: 1},
+					{Spec: gnuSpec(0), Name: "-n", Value: "5", HasValue: true, Index: 2, ValueIndex: 2},
+					{Spec: gnuSpec(0), Name: "--lines", Value: "10", HasValue: true, Index: 3, ValueIndex: 3},
+					{Spec: gnuSpec(0), Name: "--lines", Value: "20", HasValue: true, Index: 4, ValueIndex: 5},
+				},
+				End: 6,
+			},
+		},
+		{
+			grammar: gnu,
+			args:    "--color -config app.yml -n=3 --unknown=1 -qz -xq -- -v",
+			expected: &ParsedOptions{
+				Options: []ParsedOption{
+					{Spec: gnuSpec(4), Name: "--color", Index: 0, ValueIndex: 0},
+					{Spec: gnuSpec(5), Name: "-config", Value: "app.yml", HasValue: true, Index: 1, ValueIndex: 2},
+					{Spec: gnuSpec(0), Name: "-n", Value: "3", HasValue: true, Index: 3, ValueIndex: 3},
+					{Name: "--unknown", Value: "1", HasValue: true, Index: 4, ValueIndex: 4},
+					{Name: "-qz", Index: 5, ValueIndex: 5},
+					{Spec: gnuSpec(1), Name: "-x", Index: 6, ValueIndex: 6},
+					{Name: "-q", Index: 6, ValueIndex: 6},
+				},
+				End: 8,
+			},
+		},
+		{
+			grammar: gnu,
+			args:    "-n",
+			expected: &ParsedOptions{
+				Options: []ParsedOption{
+					{Spec: gnuSpec(0), Name: "-n", Index: 0, ValueIndex: 0},
+				},
+				End: 1,
+			},
+		},
+		{
+			grammar: &interspersed,
+			args:    "run -v - --lines 3 job -- -x",
+			expected: &ParsedOptions{
+				Options: []ParsedOption{
+					{Spec: gnuSpec(2), Name: "-v", Index: 1, ValueIndex: 1},
+					{Spec: gnuSpec(0), Name: "--lines", Value: "3", HasValue: true, Index: 3, ValueIndex: 4},
+				},
+				Positionals: []int{0, 2, 5, 7},
+				End:         8,
+			},
+		},
+		{
+			grammar: &limited,
+			args:    "-v run -x",
+			expected: &ParsedOptions{
+				Options: []ParsedOption{
+					{Spec: gnuSpec(2), Name: "-v", Index: 0, ValueIndex: 0},
+				},
+				Positionals: []int{1},
+				End:         2,
+			},
+		},
+		{
+			grammar: &posix,
+			args:    "-v run -x",
+			expected: &ParsedOptions{
+				Options: []ParsedOption{
+					{Spec: gnuSpec(2), Name: "-v", Index: 0, ValueIndex: 0},
+				},
+				End: 1,
+			},
+		},
+		{
+			grammar: java,
+			args:    "-Dapp.name=x -cp lib/* -Xmx1g --class-path=lib -jar app.jar -cp",
+			expected: &ParsedOptions{
+				Options: []ParsedOption{
+					{Spec: &java.Options[2], Name: "-D", Value: "app.name=x", HasValue: true, Index: 0, ValueIndex: 0},
+					{Spec: &java.Options[0], Name: "-cp", Value: "lib/*", HasValue: true, Index: 1, ValueIndex: 2},
+					{Name: "-Xmx1g", Index: 3, ValueIndex: 3},
+					{Spec: &java.Options[0], Name: "--class-path", Value: "lib", HasValue: true, Index: 4, ValueIndex: 4},
+					{Spec: &java.Options[1], Name: "-jar", Value: "app.jar", HasValue: true, Index: 5, ValueIndex: 6},
+				},
+				End: 7,
+			},
+		},
+		{
+			grammar: windows,
+			args:    "-K netsvcs /P /ProcessId:{AB89} /s Schedule",
+			expected: &ParsedOptions{
+				Options: []ParsedOption{
+					{Spec: &windows.Options[0], Name: "-K", Value: "netsvcs", HasValue: true, Index: 0, ValueIndex: 1},
+					{Spec: &windows.Options[2], Name: "/P", Index: 2, ValueIndex: 2},
+					{Spec: &windows.Options[1], Name: "/ProcessId", Value: "{AB89}", HasValue: true, Index: 3, ValueIndex: 3},
+					{Name: "/s", Index: 4, ValueIndex: 4},
+				},
+				End: 5,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		parsed := tt.grammar.ParseOptions(strings.Fields(tt.args))
+		assert.Equal(t, tt.expected, parsed, tt.args)
+	}
+
+	parsed := java.ParseOptions([]string{"-cp", "lib", "-jar", "app.jar"})
+	if assert.NotNil(t, parsed.Terminal()) {
+		assert.Equal(t, "app.jar", parsed.Terminal().Value)
+	}
+	o, ok := parsed.Lookup("--class-path")
+	assert.True(t, ok)
+	assert.Equal(t, "lib", o.Value)
+	_, ok = parsed.Lookup("-D")
+	assert.False(t, ok)
+}
+
+func TestGrammarExtractors(t *testing.T) {
+	tests := []struct {
+		cmdline  string
+		expected *CommandLine
+	}{
+		{
+			cmdline: "sudo -u dog -E /usr/bin/app --port 80",
+			expected: &CommandLine{
+				Sub: &SubCommand{Command: "/usr/bin/app", Args: []string{"--port", "80"}},
+			},
+		},
+		{
+			cmdline: "sudo -nu dog -- LANG=C app",
+			expected: &CommandLine{
+				Sub: &SubCommand{Command: "app", Args: []string{}},
+			},
+		},
+		{
+			cmdline: "sudo --preserve-env=PATH --chdir=/srv app",
+			expected: &CommandLine{
+				Sub: &SubCommand{Command: "app", Args: []string{}},
+			},
+		},
+		{
+			cmdline: "python3 -W ignore -X dev app.py",
+			expected: &CommandLine{
+				Python: &PythonArgs{FilePath: "app.py", Args: []string{}},
+			},
+		},
+		{
+			cmdline: "python3 -uBmhttp.server 8000",
+			expected: &CommandLine{
+				Python: &PythonArgs{FilePath: "http.server", Args: []string{"8000"}},
+			},
+		},
+		{
+			cmdline: "python3 -c 'import app' app.py",
+			expected: &CommandLine{
+				Diagnostics: []Diagnostic{{Index: -1, Err: ErrNoScript}},
+			},
+		},
+		{
+			cmdline: "ruby -I lib -rbundler/setup -W0 bin/server -p 80",
+			expected: &CommandLine{
+				Ruby: &RubyArgs{FilePath: "bin/server", Args: []string{"-p", "80"}},
+			},
+		},
+		{
+			cmdline: "ruby -e 'puts 1' app.rb",
+			expected: &CommandLine{
+				Diagnostics: []Diagnostic{{Index: -1, Err: ErrNoScript}},
+			},
+		},
+		{
+			cmdline: "java -ea -server kafka.Kafka config/server.properties",
+			expected: &CommandLine{
+				Java: &JavaArgs{ClassName: "kafka.Kafka", Args: []string{"config/server.properties"}},
+			},
+		},
+		{
+			cmdline: "java -p /opt/mods -m app/com.example.Main --debug",
+			expected: &CommandLine{
+				Java: &JavaArgs{ClassName: "app/com.example.Main", Args: []string{"--debug"}},
+			},
+		},
+		{
+			cmdline: "java -Dcom.sun.management.jmxremote=true -Dcom.sun.management.jmxremote.ssl=true " +
+				"-Dcom.sun.management.jmxremote.authenticate=false --class-path=/opt/lib Main",
+			expected: &CommandLine{
+				Java: &JavaArgs{ClassName: "Main", JmxEnable: true, JmxSsl: true, Args: []string{}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		c, _ := ParseCommandLine(false, tt.cmdline)
+		if !assert.NotNil(t, c, tt.cmdline) {
+			continue
+		}
+		assert.Equal(t, tt.expected.Sub, c.Sub, tt.cmdline)
+		assert.Equal(t, tt.expected.Python, c.Python, tt.cmdline)
+		assert.Equal(t, tt.expected.Ruby, c.Ruby, tt.cmdline)
+		assert.Equal(t, tt.expected.Java, c.Java, tt.cmdline)
+		assert.Equal(t, tt.expected.Diagnostics, c.Diagnostics, tt.cmdline)
+	}
+
+	c, err := ParseCommandLine(false, "python3 -umhttp.server 8000")
+	assert.NoError(t, err)
+	assert.Equal(t, "http.server", c.ServiceName())
+	assert.True(t, MustCompileMatcher(`python.module == "http.server"`).Match(c))
+}
@@ -0,0 +1,179 @@
+package cmdline
+
+import (
+	"sort"
+	"strings"
+)
+
+// Service is a group of processes that make one service, like a gunicorn
+// master with its workers or postgres with its backends
+type Service struct {
+	// Name is the service name of the leader, the product of a process
+	// title like "nginx: worker process"
+	Name string
+	// Runtime is "java", "python", "ruby", "node" or empty
+	Runtime string
+	// Leader started the other members, e.g. the master process
+	Leader *Process
+	// Members are the processes of the service by pid, Leader included
+	Members []*Process
+}
+
+// GroupProcesses groups the processes of ScanProcesses into services. A
+// process joins the service of its parent when both have the same command
+// line or the same service name, which may come from a process title like
+// "postgres: 14/main: checkpointer". The children of a process with the same
+// command line are one service too, like the workers of a supervisor, unless
+// their parent is init or not in processes. The services are sorted by the
+// pid of their leader.
+func GroupProcesses(processes []*Process) []Service {
+	byPID := make(map[int]int, len(processes))
+	for idx, p := range processes {
+		byPID[p.PID] = idx
+	}
+
+	// union-find over the indexes of processes
+	groups := make([]int, len(processes))
+	for idx := range groups {
+		groups[idx] = idx
+	}
+	var find func(idx int) int
+	find = func(idx int) int {
+		if groups[idx] != idx {
+			groups[idx] = find(groups[idx])
+		}
+		return groups[idx]
+	}
+	union := func(a, b int) {
+		if ra, rb := find(a), find(b); ra != rb {
+			groups[rb] = ra
+		}
+	}
+
+	argvs := make([]string, len(processes))
+	names := make([]string, len(processes))
+	for idx, p := range processes {
+		argvs[idx] = strings.Join(processArgv(p), "\x00")
+		names[idx] = strings.ToLower(processServiceName(p))
+	}
+
+	type siblingKey struct {
+		argv string
+		ppid int
+	}
+	siblings := map[siblingKey]int{}
+	for idx, p := range processes {
+		parent, ok := byPID[p.PPID]
+		if ok && p.PPID != p.PID && (argvs[idx] == argvs[parent] || names[idx] != "" && names[idx] == names[parent]) {
+			union(parent, idx)
+		}
+
+		// the orphans of init and the children of a parent that isn't
+		// scanned are unrelated
+		if !ok || p.PPID <= 1 {
+			continue
+		}
+		key := siblingKey{argv: argvs[idx], ppid: p.PPID}
+		if first, ok := siblings[key]; ok {
+			union(first, idx)
+		} else {
+			siblings[key] = idx
+		}
+	}
+
+	members := map[int][]*Process{}
+	var roots []int
+	for idx, p := range processes {
+		root := find(idx)
+		if _, ok := members[root]; !ok {
+			roots = append(roots, root)
+		}
+		members[root] = append(members[root], p)
+	}
+
+	services := make([]Service, 0, len(roots))
+	for _, root := range roots {
+		group := members[root]
+		sort.Slice(group, func(i, j int) bool {
+			return group[i].PID < group[j].PID
+		})
+
+		leader := groupLeader(group)
+		svc := Service{
+			Name:    processServiceName(leader),
+			Leader:  leader,
+			Members: group,
+		}
+		for _, p := range append([]*Process{leader}, group...) {
+			if p.CommandLine != nil {
+				if svc.Runtime = p.CommandLine.runtime(); svc.Runtime != "" {
+					break
+				}
+			}
+		}
+		services = append(services, svc)
+	}
+	sort.Slice(services, func(i, j int) bool {
+		return services[i].Leader.PID < services[j].Leader.PID
+	})
+	return services
+}
+
+// groupLeader is the member whose parent isn't a member, a master process
+// or the first one when there are several
+func groupLeader(group []*Process) *Process {
+	pids := make(map[int]bool, len(group))
+	for _, p := range group {
+		pids[p.PID] = true
+	}
+
+	var leader *Process
+	for _, p := range group {
+		if pids[p.PPID] && p.PPID != p.PID {
+			continue
+		}
+		if _, role, ok := processTitle(p); ok && strings.HasPrefix(role, "master") {
+			return p
+		}
+		if leader == nil {
+			leader = p
+		}
+	}
+	if leader == nil {
+		leader = group[0]
+	}
+	return leader
+}
+
+func processArgv(p *Process) []string {
+	if p.CommandLine == nil {
+		return nil
+	}
+	return p.CommandLine.Argv()
+}
+
+// processServiceName is the product of the process title or else the
+// service name of the command line
+func processServiceName(p *Process) string {
+	if product, _, ok := processTitle(p); ok {
+		return product
+	}
+	if p.CommandLine == nil || p.CommandLine.ExecutePath == "" {
+		return p.Comm
+	}
+	return p.CommandLine.ServiceName()
+}
+
+// processTitle splits a title a process gave itself, like "nginx: worker
+// process" or "sshd: alice@pts/0", into the product and its role
+func processTitle(p *Process) (string, string, bool) {
+	if p.CommandLine == nil {
+		return "", "", false
+	}
+	title := strings.Join(p.CommandLine.Argv(), " ")
+	product, role, ok := strings.Cut(title, ": ")
+	if !ok || product == "" || strings.ContainsAny(product, " \t") {
+		return "", "", false
+	}
+	return executableName(false, product), strings.TrimSpace(role), true
+}
@@ -0,0 +1,79 @@
+package cmdline
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGroupProcesses(t *testing.T) {
+	processes, err := ScanProcesses("testdata/procfs/forking")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type group struct {
+		Name    string
+		Runtime string
+		Leader  int
+		Members []int
+	}
+	var actual []group
+	for _, svc := range GroupProcesses(processes) {
+		g := group{Name: svc.Name, Runtime: svc.Runtime, Leader: svc.Leader.PID}
+		for _, p := range svc.Members {
+			g.Members = append(g.Members, p.PID)
+		}
+		actual = append(actual, g)
+	}
+
+	assert.Equal(t, []group{
+		{Name: "init", Leader: 1, Members: []int{1}},
+		{Name: "nginx", Leader: 500, Members: []int{500, 501, 502, 503}},
+		{Name: "gunicorn", Runtime: "python", Leader: 600, Members: []int{600, 601, 602, 603}},
+		{Name: "postgres", Leader: 700, Members: []int{700, 701, 702, 703}},
+		// the same name, but not the same service
+		{Name: "app", Runtime: "java", Leader: 800, Members: []int{800}},
+		{Name: "app", Runtime: "java", Leader: 801, Members: []int{801}},
+		{Name: "supervisord", Runtime: "python", Leader: 900, Members: []int{900}},
+		{Name: "worker", Runtime: "python", Leader: 901, Members: []int{901, 902}},
+		{Name: "sshd", Leader: 1000, Members: []int{1000, 1001, 1002}},
+		{Name: "-bash", Leader: 1003, Members: []int{1003}},
+	}, actual)
+}
+
+func TestGroupProcessesLeader(t *testing.T) {
+	// a restarted master is younger than its workers
+	master := &Process{PID: 300, PPID: 1, CommandLine: &CommandLine{ExecutePath: "php-fpm: master process (/etc/php/fpm.conf)"}}
+	workers := []*Process{
+		{PID: 120, PPID: 300, CommandLine: &CommandLine{ExecutePath: "php-fpm: pool www"}},
+		{PID: 121, PPID: 300, CommandLine: &CommandLine{ExecutePath: "php-fpm: pool www"}},
+	}
+
+	services := GroupProcesses(append(workers, master))
+	if assert.Len(t, services, 1) {
+		assert.Equal(t, "php-fpm", services[0].Name)
+		assert.Same(t, master, services[0].Leader)
+		assert.Equal(t, []*Process{workers[0], workers[1], master}, services[0].Members)
+	}
+
+	assert.Empty(t, GroupProcesses(nil))
+}
+
+func TestGroupProcessesOrphans(t *testing.T) {
+	// the same daemon started twice by init, and twice by a parent that
+	// isn't scanned, are four services
+	processes := []*Process{
+		{PID: 200, PPID: 1, CommandLine: &CommandLine{ExecutePath: "/usr/bin/agent", Args: []string{"--once"}}},
+		{PID: 201, PPID: 1, CommandLine: &CommandLine{ExecutePath: "/usr/bin/agent", Args: []string{"--once"}}},
+		{PID: 300, PPID: 42, CommandLine: &CommandLine{ExecutePath: "/usr/bin/agent", Args: []string{"--once"}}},
+		{PID: 301, PPID: 42, CommandLine: &CommandLine{ExecutePath: "/usr/bin/agent", Args: []string{"--once"}}},
+	}
+
+	services := GroupProcesses(processes)
+	if assert.Len(t, services, 4) {
+		for idx, svc := range services {
+			assert.Equal(t, []*Process{processes[idx]}, svc.Members)
+		}
+	}
+}
@@ -0,0 +1,316 @@
+package cmdline
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SchemaVersion is the version of the JSON and YAML form of CommandLine,
+// described by commandline.schema.json.
+//
+// The version only changes when a field is removed or changes its meaning,
+// fields may be added to a version. Decoding a newer version fails.
+const SchemaVersion = 1
+
+// Values of "runtime" in the JSON and YAML form, it names the one of
+// "sub", "ruby", "python", "java" and "windows" that is set
+const (
+	RuntimeNone    = ""
+	RuntimeCommand = "command"
+	RuntimeRuby    = "ruby"
+	RuntimePython  = "python"
+	RuntimeJava    = "java"
+	RuntimeWindows = "windows"
+)
+
+type commandLineV1 struct {
+	Version     int               `json:"version" yaml:"version" doc:"schema version, 1"`
+	Env         []string          `json:"env,omitempty" yaml:"env,omitempty" doc:"NAME=value assignments in front of the executable"`
+	ExecutePath string            `json:"execute_path" yaml:"execute_path" doc:"executable as found on the command line"`
+	Args        []string          `json:"args" yaml:"args" doc:"arguments after the executable"`
+	Expansions  []expansionV1     `json:"expansions,omitempty" yaml:"expansions,omitempty" doc:"arguments with expanded environment references"`
+	WorkingDir  string            `json:"working_dir,omitempty" yaml:"working_dir,omitempty" doc:"directory the process runs in"`
+	Runtime     string            `json:"runtime,omitempty" yaml:"runtime,omitempty" doc:"which of sub, ruby, python, java and windows is set" enum:"command,ruby,python,java,windows"`
+	RuntimeInfo *runtimeInfoV1    `json:"runtime_info,omitempty" yaml:"runtime_info,omitempty" doc:"interpreter or virtual machine of the executable"`
+	Resolved    *resolutionV1     `json:"resolved,omitempty" yaml:"resolved,omitempty" doc:"executable with its symbolic links followed"`
+	ViaShebang  bool              `json:"via_shebang,omitempty" yaml:"via_shebang,omitempty" doc:"the executable is the interpreter of the #! line of a script"`
+	ScriptIndex int               `json:"script_index,omitempty" yaml:"script_index,omitempty" doc:"index in args of the script run through its #! line"`
+	Sub         *subCommandV1     `json:"sub,omitempty" yaml:"sub,omitempty" doc:"command run by a wrapper like sudo"`
+	Ruby        *scriptV1         `json:"ruby,omitempty" yaml:"ruby,omitempty" doc:"script run by ruby"`
+	Python      *scriptV1         `json:"python,omitempty" yaml:"python,omitempty" doc:"script or module run by python"`
+	Java        *javaV1           `json:"java,omitempty" yaml:"java,omitempty" doc:"main class or jar run by java"`
+	Windows     *windowsServiceV1 `json:"windows,omitempty" yaml:"windows,omitempty" doc:"component run by a windows service host"`
+	Custom      *customV1         `json:"custom,omitempty" yaml:"custom,omitempty" doc:"values found by a rule of a rule file"`
+	Diagnostics []diagnosticV1    `json:"diagnostics,omitempty" yaml:"diagnostics,omitempty" doc:"problems that left the result partial"`
+}
+
+type expansionV1 struct {
+	Index    int    `json:"index" yaml:"index" doc:"index in args, -1 for execute_path"`
+	Original string `json:"original" yaml:"original" doc:"the argument before the expansion"`
+}
+
+type runtimeInfoV1 struct {
+	Name    string `json:"name" yaml:"name" doc:"the runtime" enum:"python,ruby,java,node,php,perl"`
+	Version string `json:"version,omitempty" yaml:"version,omitempty" doc:"dotted version, like 3.11"`
+	Manager string `json:"manager,omitempty" yaml:"manager,omitempty" doc:"version manager of the executable" enum:"pyenv,rbenv,asdf,sdkman,nvm"`
+}
+
+type resolutionV1 struct {
+	Path  string   `json:"path" yaml:"path" doc:"executable the links lead to"`
+	Links []string `json:"links" yaml:"links" doc:"path after each link followed, the last one is path"`
+}
+
+type customV1 struct {
+	Rule   string        `json:"rule" yaml:"rule" doc:"name of the rule"`
+	Values []roleValueV1 `json:"values" yaml:"values" doc:"values of the options and positionals with a role"`
+}
+
+type roleValueV1 struct {
+	Role  string `json:"role" yaml:"role" doc:"what the value is, like config or port"`
+	Value string `json:"value" yaml:"value" doc:"the value"`
+	Index int    `json:"index" yaml:"index" doc:"index in args"`
+}
+
+type diagnosticV1 struct {
+	Index      int    `json:"index" yaml:"index" doc:"index in args, -1 for the whole command line"`
+	Message    string `json:"message" yaml:"message" doc:"what is wrong"`
+	Option     string `json:"option,omitempty" yaml:"option,omitempty" doc:"the unknown option"`
+	Executable string `json:"executable,omitempty" yaml:"executable,omitempty" doc:"the executable that doesn't know the option"`
+}
+
+type subCommandV1 struct {
+	Command string   `json:"command" yaml:"command" doc:"wrapped executable"`
+	Args    []string `json:"args" yaml:"args" doc:"arguments of the wrapped executable"`
+
+	CommandLine *commandLineV1 `json:"commandline,omitempty" yaml:"commandline,omitempty" doc:"the parsed wrapped command"`
+}
+
+type scriptV1 struct {
+	FilePath string   `json:"file_path" yaml:"file_path" doc:"script path or module name"`
+	Args     []string `json:"args" yaml:"args" doc:"arguments of the script"`
+}
+
+type javaV1 struct {
+	ClassName       string   `json:"class_name" yaml:"class_name" doc:"main class, or the jar with -jar"`
+	JmxEnable       bool     `json:"jmx_enable" yaml:"jmx_enable" doc:"-Dcom.sun.management.jmxremote"`
+	JmxPort         string   `json:"jmx_port" yaml:"jmx_port" doc:"-Dcom.sun.management.jmxremote.port"`
+	JmxSsl          bool     `json:"jmx_ssl" yaml:"jmx_ssl" doc:"-Dcom.sun.management.jmxremote.ssl"`
+	JmxAuthenticate bool     `json:"jmx_authenticate" yaml:"jmx_authenticate" doc:"-Dcom.sun.management.jmxremote.authenticate"`
+	Args            []string `json:"args" yaml:"args" doc:"arguments of the main class"`
+}
+
+type windowsServiceV1 struct {
+	Host        string   `json:"host" yaml:"host" doc:"lower-cased name of the host binary" enum:"svchost,rundll32,dllhost,msiexec,taskhostw"`
+	Group       string   `json:"group,omitempty" yaml:"group,omitempty" doc:"svchost -k"`
+	ServiceName string   `json:"service_name,omitempty" yaml:"service_name,omitempty" doc:"svchost -s"`
+	Policy      bool     `json:"policy,omitempty" yaml:"policy,omitempty" doc:"svchost -p"`
+	DLL         string   `json:"dll,omitempty" yaml:"dll,omitempty" doc:"dll run by rundll32"`
+	EntryPoint  string   `json:"entry_point,omitempty" yaml:"entry_point,omitempty" doc:"entry point run by rundll32"`
+	ProcessID   string   `json:"process_id,omitempty" yaml:"process_id,omitempty" doc:"dllhost /Processid"`
+	Action      string   `json:"action,omitempty" yaml:"action,omitempty" doc:"what msiexec does with the package"`
+	Package     string   `json:"package,omitempty" yaml:"package,omitempty" doc:"package of msiexec"`
+	Task        string   `json:"task,omitempty" yaml:"task,omitempty" doc:"task of taskhostw"`
+	Args        []string `json:"args" yaml:"args" doc:"remaining arguments"`
+}
+
+// args are always written as arrays, never as null
+func nonNilArgs(args []string) []string {
+	if args == nil {
+		return []string{}
+	}
+	return args
+}
+
+func (c *CommandLine) toV1() *commandLineV1 {
+	v := &commandLineV1{
+		Version:     SchemaVersion,
+		Env:         c.Env,
+		ExecutePath: c.ExecutePath,
+		Args:        nonNilArgs(c.Args),
+		WorkingDir:  c.WorkingDir,
+		ViaShebang:  c.ViaShebang,
+		ScriptIndex: c.ScriptIndex,
+	}
+	for _, e := range c.Expansions {
+		v.Expansions = append(v.Expansions, expansionV1{Index: e.Index, Original: e.Original})
+	}
+	if r := c.Runtime; r != nil {
+		v.RuntimeInfo = &runtimeInfoV1{Name: r.Name, Version: r.Version, Manager: r.Manager}
+	}
+	if r := c.Resolved; r != nil {
+		v.Resolved = &resolutionV1{Path: r.Path, Links: r.Links}
+	}
+	if c.Sub != nil {
+		v.Sub = &subCommandV1{Command: c.Sub.Command, Args: nonNilArgs(c.Sub.Args)}
+		if c.Sub.CommandLine != nil {
+			v.Sub.CommandLine = c.Sub.CommandLine.toV1()
+		}
+		v.Runtime = RuntimeCommand
+	}
+	if c.Ruby != nil {
+		v.Ruby = &scriptV1{FilePath: c.Ruby.FilePath, Args: nonNilArgs(c.Ruby.Args)}
+		v.Runtime = RuntimeRuby
+	}
+	if c.Python != nil {
+		v.Python = &scriptV1{FilePath: c.Python.FilePath, Args: nonNilArgs(c.Python.Args)}
+		v.Runtime = RuntimePython
+	}
+	if c.Java != nil {
+		v.Java = &javaV1{
+			ClassName:       c.Java.ClassName,
+			JmxEnable:       c.Java.JmxEnable,
+			JmxPort:         c.Java.JmxPort,
+			JmxSsl:          c.Java.JmxSsl,
+			JmxAuthenticate: c.Java.JmxAuthenticate,
+			Args:            nonNilArgs(c.Java.Args),
+		}
+		v.Runtime = RuntimeJava
+	}
+	if w := c.Windows; w != nil {
+		v.Windows = &windowsServiceV1{
+			Host:        w.Host,
+			Group:       w.Group,
+			ServiceName: w.ServiceName,
+			Policy:      w.Policy,
+			DLL:         w.DLL,
+			EntryPoint:  w.EntryPoint,
+			ProcessID:   w.ProcessID,
+			Action:      w.Action,
+			Package:     w.Package,
+			Task:        w.Task,
+			Args:        nonNilArgs(w.Args),
+		}
+		v.Runtime = RuntimeWindows
+	}
+	if c.Custom != nil {
+		v.Custom = &customV1{Rule: c.Custom.Rule, Values: []roleValueV1{}}
+		for _, rv := range c.Custom.Values {
+			v.Custom.Values = append(v.Custom.Values, roleValueV1{Role: rv.Role, Value: rv.Value, Index: rv.Index})
+		}
+	}
+	for _, d := range c.Diagnostics {
+		diagnostic := diagnosticV1{Index: d.Index, Message: d.Err.Error()}
+		var unknown *UnknownOptionError
+		if errors.As(d.Err, &unknown) {
+			diagnostic.Option, diagnostic.Executable = unknown.Option, unknown.Executable
+		}
+		v.Diagnostics = append(v.Diagnostics, diagnostic)
+	}
+	return v
+}
+
+func (v *commandLineV1) toCommandLine() (*CommandLine, error) {
+	if v.Version > SchemaVersion {
+		return nil, errors.New("unsupported commandline schema version " + strconv.Itoa(v.Version))
+	}
+
+	c := &CommandLine{
+		Env:         v.Env,
+		ExecutePath: v.ExecutePath,
+		Args:        v.Args,
+		WorkingDir:  v.WorkingDir,
+		ViaShebang:  v.ViaShebang,
+		ScriptIndex: v.ScriptIndex,
+	}
+	for _, e := range v.Expansions {
+		c.Expansions = append(c.Expansions, Expansion{Index: e.Index, Original: e.Original})
+	}
+	if r := v.RuntimeInfo; r != nil {
+		c.Runtime = &Runtime{Name: r.Name, Version: r.Version, Manager: r.Manager}
+	}
+	if r := v.Resolved; r != nil {
+		c.Resolved = &Resolution{Path: r.Path, Links: r.Links}
+	}
+	if v.Sub != nil {
+		c.Sub = &SubCommand{Command: v.Sub.Command, Args: v.Sub.Args}
+		if v.Sub.CommandLine != nil {
+			sub, err := v.Sub.CommandLine.toCommandLine()
+			if err != nil {
+				return nil, err
+			}
+			c.Sub.CommandLine = sub
+		}
+	}
+	if v.Ruby != nil {
+		c.Ruby = &RubyArgs{FilePath: v.Ruby.FilePath, Args: v.Ruby.Args}
+	}
+	if v.Python != nil {
+		c.Python = &PythonArgs{FilePath: v.Python.FilePath, Args: v.Python.Args}
+	}
+	if v.Java != nil {
+		c.Java = &JavaArgs{
+			ClassName:       v.Java.ClassName,
+			JmxEnable:       v.Java.JmxEnable,
+			JmxPort:         v.Java.JmxPort,
+			JmxSsl:          v.Java.JmxSsl,
+			JmxAuthenticate: v.Java.JmxAuthenticate,
+			Args:            v.Java.Args,
+		}
+	}
+	if w := v.Windows; w != nil {
+		c.Windows = &WindowsService{
+			Host:        w.Host,
+			Group:       w.Group,
+			ServiceName: w.ServiceName,
+			Policy:      w.Policy,
+			DLL:         w.DLL,
+			EntryPoint:  w.EntryPoint,
+			ProcessID:   w.ProcessID,
+			Action:      w.Action,
+			Package:     w.Package,
+			Task:        w.Task,
+			Args:        w.Args,
+		}
+	}
+	if v.Custom != nil {
+		c.Custom = &CustomArgs{Rule: v.Custom.Rule}
+		for _, rv := range v.Custom.Values {
+			c.Custom.Values = append(c.Custom.Values, RoleValue{Role: rv.Role, Value: rv.Value, Index: rv.Index})
+		}
+	}
+	for _, d := range v.Diagnostics {
+		err := diagnosticError(d.Message)
+		if d.Option != "" {
+			err = &UnknownOptionError{Executable: d.Executable, Option: d.Option, Index: d.Index}
+		}
+		c.Diagnostics = append(c.Diagnostics, Diagnostic{Index: d.Index, Err: err})
+	}
+	return c, nil
+}
+
+func (c *CommandLine) MarshalJSON() ([]byte, error) {
+	return json.Marshal(c.toV1())
+}
+
+func (c *CommandLine) UnmarshalJSON(data []byte) error {
+	var v commandLineV1
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	parsed, err := v.toCommandLine()
+	if err != nil {
+		return err
+	}
+	*c = *parsed
+	return nil
+}
+
+func (c *CommandLine) MarshalYAML() (interface{}, error) {
+	return c.toV1(), nil
+}
+
+func (c *CommandLine) UnmarshalYAML(value *yaml.Node) error {
+	var v commandLineV1
+	if err := value.Decode(&v); err != nil {
+		return err
+	}
+	parsed, err := v.toCommandLine()
+	if err != nil {
+		return err
+	}
+	*c = *parsed
+	return nil
+}
@@ -0,0 +1,246 @@
+package cmdline
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+var update = flag.Bool("update", false, "update the golden files")
+
+func checkGolden(t *testing.T, filename string, actual []byte) {
+	t.Helper()
+
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(filename), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filename, actual, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	expected, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, string(expected), string(actual))
+}
+
+func TestMarshal(t *testing.T) {
+	tests := []struct {
+		isWindows bool
+		name      string
+		cmdline   string
+		maxDepth  int
+		env       map[string]string
+		rules     string
+		fsys      fs.FS
+	}{
+		{
+			name:    "single",
+			cmdline: "./my-server.sh",
+		},
+		{
+			name:    "sudo",
+			cmdline: "sudo -E -u dog /usr/local/bin/myApp -items=0,1,2,3 -foo=bar",
+		},
+		{
+			name:    "python_module",
+			cmdline: "python3 -m hello --name world",
+		},
+		{
+			name:    "ruby",
+			cmdline: "ruby /usr/sbin/td-agent --log /var/log/td-agent/td-agent.log",
+		},
+		{
+			name:    "java_jmx",
+			cmdline: "java -Xmx1g -Dcom.sun.management.jmxremote -Dcom.sun.management.jmxremote.port=9010 -jar /opt/app.jar run",
+		},
+		{
+			isWindows: true,
+			name:      "windows_svchost",
+			cmdline:   `C:\Windows\system32\svchost.exe -k netsvcs -p -s Schedule`,
+		},
+		{
+			name:    "python_diagnostics",
+			cmdline: "python3 -u",
+		},
+		{
+			isWindows: true,
+			name:      "windows_diagnostics",
+			cmdline:   `svchost.exe -x`,
+		},
+		{
+			name:     "sudo_nested",
+			cmdline:  "sudo -u dog sudo -E python3 -m http.server 8080",
+			maxDepth: 2,
+		},
+		{
+			name:    "env",
+			cmdline: "LANG=C JAVA_HOME=/usr/lib/jvm/java-17 $JAVA_HOME/bin/java -jar ${APP_JAR:-/opt/app.jar}",
+			env:     map[string]string{"JAVA_HOME": "/usr/lib/jvm/java-17"},
+		},
+		{
+			name:    "custom",
+			cmdline: "acme-runner --pid-file /run/acme.pid nightly jobs/cleanup.py --dry-run",
+			rules:   "testdata/rules/acme.yaml",
+		},
+		{
+			name:    "resolved",
+			cmdline: "/usr/bin/python -m http.server 8080",
+			fsys: linkFS{fstest.MapFS{
+				"usr/bin/python":          link("/etc/alternatives/python"),
+				"etc/alternatives/python": link("/usr/bin/python3.9"),
+				"usr/bin/python3.9":       &fstest.MapFile{},
+			}},
+		},
+		{
+			name:    "shebang",
+			cmdline: "/usr/local/bin/celery -A proj worker",
+			fsys: fstest.MapFS{
+				"usr/local/bin/celery": script("#!/usr/bin/env -S LANG=C python3 -u"),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flavor := FlavorPOSIX
+			if tt.isWindows {
+				flavor = FlavorWindows
+			}
+			registry := NewRegistry()
+			if tt.rules != "" {
+				if err := registry.LoadRuleFile(tt.rules); err != nil {
+					t.Fatal(err)
+				}
+			}
+			p := NewParser(WithFlavor(flavor), WithStrictness(StrictnessLenient), WithMaxDepth(tt.maxDepth), WithEnv(tt.env), WithRegistry(registry), WithFS(tt.fsys))
+			c, err := p.ParseCommandLine(tt.cmdline)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+
+			jsonData, err := json.MarshalIndent(c, "", "  ")
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			checkGolden(t, filepath.Join("testdata", "marshal", tt.name+".json"), append(jsonData, '\n'))
+
+			yamlData, err := yaml.Marshal(c)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			checkGolden(t, filepath.Join("testdata", "marshal", tt.name+".yaml"), yamlData)
+
+			var fromJSON CommandLine
+			if err := json.Unmarshal(jsonData, &fromJSON); err != nil {
+				t.Error(err)
+				return
+			}
+			assert.Equal(t, c, &fromJSON)
+
+			var fromYAML CommandLine
+			if err := yaml.Unmarshal(yamlData, &fromYAML); err != nil {
+				t.Error(err)
+				return
+			}
+			assert.Equal(t, c, &fromYAML)
+		})
+	}
+}
+
+func TestUnmarshalVersion(t *testing.T) {
+	var c CommandLine
+	if err := json.Unmarshal([]byte(`{"execute_path":"java","args":[],"unknown":1}`), &c); err != nil {
+		t.Error(err)
+	}
+	assert.Equal(t, "java", c.ExecutePath)
+
+	err := json.Unmarshal([]byte(`{"version":2,"execute_path":"java","args":[]}`), &c)
+	if err == nil {
+		t.Error("want error got ok")
+	}
+	err = yaml.Unmarshal([]byte("version: 2\nexecute_path: java\n"), &c)
+	if err == nil {
+		t.Error("want error got ok")
+	}
+}
+
+// jsonSchema builds the JSON Schema of the wire types from their tags
+func jsonSchema(typ reflect.Type) map[string]interface{} {
+	switch typ.Kind() {
+	case reflect.Ptr:
+		if typ.Elem() == reflect.TypeOf(commandLineV1{}) {
+			// the command of a wrapper is a nested command line
+			return map[string]interface{}{"$ref": "#"}
+		}
+		return jsonSchema(typ.Elem())
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Slice:
+		return map[string]interface{}{"type": "array", "items": jsonSchema(typ.Elem())}
+	}
+
+	properties := map[string]interface{}{}
+	required := []string{}
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		name, opts, _ := strings.Cut(field.Tag.Get("json"), ",")
+
+		schema := jsonSchema(field.Type)
+		schema["description"] = field.Tag.Get("doc")
+		if enum := field.Tag.Get("enum"); enum != "" {
+			schema["enum"] = strings.Split(enum, ",")
+		}
+		if name == "version" && typ == reflect.TypeOf(commandLineV1{}) {
+			schema["minimum"] = 1
+			schema["maximum"] = SchemaVersion
+		}
+		properties[name] = schema
+		if opts != "omitempty" {
+			required = append(required, name)
+		}
+	}
+	return map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+		"required":   required,
+	}
+}
+
+func TestJSONSchema(t *testing.T) {
+	schema := jsonSchema(reflect.TypeOf(commandLineV1{}))
+	schema["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	schema["$id"] = "https://github.com/mei-rune/cmdline/commandline.schema.json"
+	schema["title"] = "CommandLine"
+	schema["description"] = "A parsed command line, generated by TestJSONSchema, update with go test -run TestJSONSchema -update"
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetIndent("", "  ")
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(schema); err != nil {
+		t.Fatal(err)
+	}
+	checkGolden(t, "commandline.schema.json", buf.Bytes())
+}
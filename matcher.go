@@ -0,0 +1,637 @@
+package cmdline
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Matcher selects command lines
+type Matcher interface {
+	Match(c *CommandLine) bool
+}
+
+// MatcherError means a matcher expression doesn't compile
+type MatcherError struct {
+	Expr string
+	// Offset is the byte offset in Expr where the problem starts
+	Offset int
+	Reason string
+}
+
+func (e *MatcherError) Error() string {
+	return "invalid matcher - " + e.Reason + " at offset " + strconv.Itoa(e.Offset) + " in `" + e.Expr + "`"
+}
+
+// the fields of the matcher expressions, looked up when compiling
+var matcherFields = map[string]func(c *CommandLine) string{
+	"exe": func(c *CommandLine) string {
+		return c.ExecutePath
+	},
+	"exe.base": func(c *CommandLine) string {
+		return executableName(c.isWindows(), c.ExecutePath)
+	},
+	"exe.resolved": func(c *CommandLine) string {
+		if c.Resolved == nil {
+			return c.ExecutePath
+		}
+		return c.Resolved.Path
+	},
+	"shebang": func(c *CommandLine) string {
+		if !c.ViaShebang || c.ScriptIndex >= len(c.Args) {
+			return ""
+		}
+		return c.Args[c.ScriptIndex]
+	},
+	"name": func(c *CommandLine) string {
+		return c.ServiceName()
+	},
+	"runtime": func(c *CommandLine) string {
+		return c.runtime()
+	},
+	"runtime.version": func(c *CommandLine) string {
+		if c.Runtime == nil {
+			return ""
+		}
+		return c.Runtime.Version
+	},
+	"runtime.manager": func(c *CommandLine) string {
+		if c.Runtime == nil {
+			return ""
+		}
+		return c.Runtime.Manager
+	},
+	"cmdline": func(c *CommandLine) string {
+		return c.String(QuotePOSIX)
+	},
+	"workdir": func(c *CommandLine) string {
+		return c.WorkingDir
+	},
+	"java.main": func(c *CommandLine) string {
+		if c.Java == nil {
+			return ""
+		}
+		return c.Java.ClassName
+	},
+	"java.jmx": func(c *CommandLine) string {
+		return strconv.FormatBool(c.Java != nil && c.Java.JmxEnable)
+	},
+	"java.jmx.port": func(c *CommandLine) string {
+		if c.Java == nil {
+			return ""
+		}
+		return c.Java.JmxPort
+	},
+	"python.script": func(c *CommandLine) string {
+		if c.Python == nil {
+			return ""
+		}
+		return c.Python.FilePath
+	},
+	"python.module": func(c *CommandLine) string {
+		if c.Python == nil || c.runtimeTerminal() == nil {
+			return ""
+		}
+		return c.Python.FilePath
+	},
+	"ruby.script": func(c *CommandLine) string {
+		if c.Ruby == nil {
+			return ""
+		}
+		return c.Ruby.FilePath
+	},
+	"sub.command": func(c *CommandLine) string {
+		if c.Sub == nil {
+			return ""
+		}
+		return c.Sub.Command
+	},
+	"sub.base": func(c *CommandLine) string {
+		if c.Sub == nil {
+			return ""
+		}
+		return executableName(c.isWindows(), c.Sub.Command)
+	},
+	"windows.host": func(c *CommandLine) string {
+		if c.Windows == nil {
+			return ""
+		}
+		return c.Windows.Host
+	},
+	"windows.service": func(c *CommandLine) string {
+		if c.Windows == nil {
+			return ""
+		}
+		return windowsServiceName(c.Windows)
+	},
+	"custom.rule": func(c *CommandLine) string {
+		if c.Custom == nil {
+			return ""
+		}
+		return c.Custom.Rule
+	},
+}
+
+var matcherListFields = map[string]func(c *CommandLine) []string{
+	"args": func(c *CommandLine) []string {
+		return c.Args
+	},
+	"env": func(c *CommandLine) []string {
+		return c.Env
+	},
+	"java.args": func(c *CommandLine) []string {
+		if c.Java == nil {
+			return nil
+		}
+		return c.Java.Args
+	},
+	"python.args": func(c *CommandLine) []string {
+		if c.Python == nil {
+			return nil
+		}
+		return c.Python.Args
+	},
+	"ruby.args": func(c *CommandLine) []string {
+		if c.Ruby == nil {
+			return nil
+		}
+		return c.Ruby.Args
+	},
+	"sub.args": func(c *CommandLine) []string {
+		if c.Sub == nil {
+			return nil
+		}
+		return c.Sub.Args
+	},
+}
+
+var matcherMapFields = map[string]func(c *CommandLine, key string) string{
+	"java.props": func(c *CommandLine, key string) string {
+		if c.runtime() != "java" {
+			return ""
+		}
+		prefix := "-D" + key
+		value := ""
+		for _, a := range c.Args[:c.programArgsStart()] {
+			if a == prefix {
+				value = ""
+			} else if v, ok := strings.CutPrefix(a, prefix+"="); ok {
+				value = v
+			}
+		}
+		return value
+	},
+	"env": func(c *CommandLine, key string) string {
+		value := ""
+		for _, e := range c.Env {
+			if v, ok := strings.CutPrefix(e, key+"="); ok {
+				value = v
+			}
+		}
+		return value
+	},
+	"custom": func(c *CommandLine, key string) string {
+		if c.Custom == nil {
+			return ""
+		}
+		return c.Custom.Value(key)
+	},
+}
+
+// CompileMatcher compiles an expression over the parsed fields of a command
+// line, like
+//
+//	runtime == "java" && java.main =~ "Kafka" && java.props["kafka.logs.dir"] != ""
+//	exe.base in ("nginx", "httpd") || "--debug" in args
+//
+// The operators are ==, !=, =~ and !~ with a regular expression, in and
+// not in with a list or a list field, !, && and ||. A field alone is true
+// when it is neither empty nor "false", a list field when it is not empty.
+//
+// The fields are exe, exe.base, exe.resolved (exe with its links followed),
+// name (ServiceName), shebang (the script run through its "#!" line),
+// runtime, runtime.version, runtime.manager, cmdline, workdir, java.main,
+// java.jmx, java.jmx.port, python.script, python.module, ruby.script,
+// sub.command, sub.base, windows.host, windows.service and custom.rule, the
+// list fields args, env, java.args, python.args, ruby.args and sub.args, and
+// the map fields java.props["name"], env["NAME"] and custom["role"].
+func CompileMatcher(expr string) (Matcher, error) {
+	p := &matcherParser{expr: expr}
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.tok.kind != tokenEOF {
+		return nil, p.errorf("unexpected %s", p.tok)
+	}
+	return &matcher{expr: expr, root: root}, nil
+}
+
+// MustCompileMatcher is like CompileMatcher but panics on errors, for
+// matchers in variables
+func MustCompileMatcher(expr string) Matcher {
+	m, err := CompileMatcher(expr)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+type matcher struct {
+	expr string
+	root matchNode
+}
+
+func (m *matcher) Match(c *CommandLine) bool {
+	return m.root.eval(c)
+}
+
+func (m *matcher) String() string {
+	return m.expr
+}
+
+type matchNode interface {
+	eval(c *CommandLine) bool
+}
+
+type andNode struct{ left, right matchNode }
+
+func (n *andNode) eval(c *CommandLine) bool { return n.left.eval(c) && n.right.eval(c) }
+
+type orNode struct{ left, right matchNode }
+
+func (n *orNode) eval(c *CommandLine) bool { return n.left.eval(c) || n.right.eval(c) }
+
+type notNode struct{ node matchNode }
+
+func (n *notNode) eval(c *CommandLine) bool { return !n.node.eval(c) }
+
+// operand is a string literal or a string field
+type operand struct {
+	field   func(c *CommandLine) string
+	literal string
+}
+
+func (o *operand) value(c *CommandLine) string {
+	if o.field != nil {
+		return o.field(c)
+	}
+	return o.literal
+}
+
+type truthNode struct{ operand *operand }
+
+func (n *truthNode) eval(c *CommandLine) bool {
+	v := n.operand.value(c)
+	return v != "" && v != "false"
+}
+
+type listTruthNode struct{ list func(c *CommandLine) []string }
+
+func (n *listTruthNode) eval(c *CommandLine) bool { return len(n.list(c)) > 0 }
+
+type equalNode struct{ left, right *operand }
+
+func (n *equalNode) eval(c *CommandLine) bool { return n.left.value(c) == n.right.value(c) }
+
+type regexpNode struct {
+	operand *operand
+	re      *regexp.Regexp
+}
+
+func (n *regexpNode) eval(c *CommandLine) bool { return n.re.MatchString(n.operand.value(c)) }
+
+type inNode struct {
+	operand *operand
+	values  []string
+	list    func(c *CommandLine) []string
+}
+
+func (n *inNode) eval(c *CommandLine) bool {
+	values := n.values
+	if n.list != nil {
+		values = n.list(c)
+	}
+	return contains(values, n.operand.value(c))
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenString
+	tokenIdent
+	tokenOperator
+)
+
+type token struct {
+	kind  tokenKind
+	text  string
+	value string
+	pos   int
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tokenEOF:
+		return "end of expression"
+	case tokenString:
+		return "string " + t.text
+	}
+	return strconv.Quote(t.text)
+}
+
+type matcherParser struct {
+	expr string
+	pos  int
+	tok  token
+}
+
+func (p *matcherParser) errorf(format string, args ...interface{}) *MatcherError {
+	return &MatcherError{Expr: p.expr, Offset: p.tok.pos, Reason: fmt.Sprintf(format, args...)}
+}
+
+var matcherOperators = []string{"==", "!=", "=~", "!~", "&&", "||", "!", "(", ")", "[", "]", ","}
+
+func (p *matcherParser) next() error {
+	for p.pos < len(p.expr) && strings.IndexByte(" \t\r\n", p.expr[p.pos]) >= 0 {
+		p.pos++
+	}
+	start := p.pos
+	if p.pos == len(p.expr) {
+		p.tok = token{kind: tokenEOF, pos: start}
+		return nil
+	}
+
+	c := p.expr[p.pos]
+	switch {
+	case c == '"' || c == '`':
+		end := p.pos + 1
+		for end < len(p.expr) && p.expr[end] != c {
+			if c == '"' && p.expr[end] == '\\' {
+				end++
+			}
+			end++
+		}
+		if end >= len(p.expr) {
+			return &MatcherError{Expr: p.expr, Offset: start, Reason: "unterminated string"}
+		}
+		text := p.expr[start : end+1]
+		value, err := strconv.Unquote(text)
+		if err != nil {
+			return &MatcherError{Expr: p.expr, Offset: start, Reason: "invalid string " + text}
+		}
+		p.pos = end + 1
+		p.tok = token{kind: tokenString, text: text, value: value, pos: start}
+		return nil
+	case isIdentByte(c):
+		for p.pos < len(p.expr) && isIdentByte(p.expr[p.pos]) {
+			p.pos++
+		}
+		p.tok = token{kind: tokenIdent, text: p.expr[start:p.pos], pos: start}
+		return nil
+	}
+
+	for _, op := range matcherOperators {
+		if strings.HasPrefix(p.expr[p.pos:], op) {
+			p.pos += len(op)
+			p.tok = token{kind: tokenOperator, text: op, pos: start}
+			return nil
+		}
+	}
+	return &MatcherError{Expr: p.expr, Offset: start, Reason: "unexpected character " + strconv.QuoteRune(rune(c))}
+}
+
+func isIdentByte(c byte) bool {
+	return c == '_' || c == '.' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9'
+}
+
+func (p *matcherParser) isOperator(op string) bool {
+	return p.tok.kind == tokenOperator && p.tok.text == op
+}
+
+func (p *matcherParser) expect(op string) error {
+	if !p.isOperator(op) {
+		return p.errorf("expected %s, got %s", strconv.Quote(op), p.tok)
+	}
+	return p.next()
+}
+
+func (p *matcherParser) parseOr() (matchNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOperator("||") {
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &orNode{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *matcherParser) parseAnd() (matchNode, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOperator("&&") {
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &andNode{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *matcherParser) parseUnary() (matchNode, error) {
+	if p.isOperator("!") {
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		node, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &notNode{node: node}, nil
+	}
+
+	if p.isOperator("(") {
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		node, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		return node, p.expect(")")
+	}
+	return p.parseComparison()
+}
+
+func (p *matcherParser) parseComparison() (matchNode, error) {
+	// a list field alone
+	if p.tok.kind == tokenIdent {
+		if list, ok := matcherListFields[p.tok.text]; ok {
+			if _, isMap := matcherMapFields[p.tok.text]; !isMap || !strings.HasPrefix(p.expr[p.pos:], "[") {
+				if err := p.next(); err != nil {
+					return nil, err
+				}
+				return &listTruthNode{list: list}, nil
+			}
+		}
+	}
+
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case p.isOperator("==") || p.isOperator("!="):
+		negate := p.tok.text == "!="
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		right, err := p.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		return negated(&equalNode{left: left, right: right}, negate), nil
+	case p.isOperator("=~") || p.isOperator("!~"):
+		negate := p.tok.text == "!~"
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		if p.tok.kind != tokenString {
+			return nil, p.errorf("expected a regular expression string, got %s", p.tok)
+		}
+		re, err := regexp.Compile(p.tok.value)
+		if err != nil {
+			return nil, p.errorf("invalid regular expression: %v", err)
+		}
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		return negated(&regexpNode{operand: left, re: re}, negate), nil
+	case p.tok.kind == tokenIdent && (p.tok.text == "in" || p.tok.text == "not"):
+		negate := p.tok.text == "not"
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		if negate {
+			if p.tok.kind != tokenIdent || p.tok.text != "in" {
+				return nil, p.errorf("expected \"in\" after \"not\", got %s", p.tok)
+			}
+			if err := p.next(); err != nil {
+				return nil, err
+			}
+		}
+		node, err := p.parseList(left)
+		if err != nil {
+			return nil, err
+		}
+		return negated(node, negate), nil
+	}
+	return &truthNode{operand: left}, nil
+}
+
+func negated(node matchNode, negate bool) matchNode {
+	if negate {
+		return &notNode{node: node}
+	}
+	return node
+}
+
+func (p *matcherParser) parseOperand() (*operand, error) {
+	switch p.tok.kind {
+	case tokenString:
+		o := &operand{literal: p.tok.value}
+		return o, p.next()
+	case tokenIdent:
+	default:
+		return nil, p.errorf("expected a field or a string, got %s", p.tok)
+	}
+
+	name := p.tok
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+
+	if p.isOperator("[") {
+		get, ok := matcherMapFields[name.text]
+		if !ok {
+			return nil, &MatcherError{Expr: p.expr, Offset: name.pos, Reason: "field " + strconv.Quote(name.text) + " is not a map"}
+		}
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		if p.tok.kind != tokenString {
+			return nil, p.errorf("expected a string key, got %s", p.tok)
+		}
+		key := p.tok.value
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		if err := p.expect("]"); err != nil {
+			return nil, err
+		}
+		return &operand{field: func(c *CommandLine) string { return get(c, key) }}, nil
+	}
+
+	field, ok := matcherFields[name.text]
+	if !ok {
+		reason := "unknown field " + strconv.Quote(name.text)
+		if _, ok := matcherListFields[name.text]; ok {
+			reason = "list field " + strconv.Quote(name.text) + " can only follow in"
+		} else if _, ok := matcherMapFields[name.text]; ok {
+			reason = "map field " + strconv.Quote(name.text) + " needs a [\"key\"]"
+		}
+		return nil, &MatcherError{Expr: p.expr, Offset: name.pos, Reason: reason}
+	}
+	return &operand{field: field}, nil
+}
+
+// parseList parses the right side of in, ("a", "b") or a list field
+func (p *matcherParser) parseList(left *operand) (matchNode, error) {
+	if p.tok.kind == tokenIdent {
+		list, ok := matcherListFields[p.tok.text]
+		if !ok {
+			return nil, p.errorf("expected a list or a list field, got %s", p.tok)
+		}
+		return &inNode{operand: left, list: list}, p.next()
+	}
+
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+	values := []string{}
+	for !p.isOperator(")") {
+		if len(values) > 0 {
+			if err := p.expect(","); err != nil {
+				return nil, err
+			}
+		}
+		if p.tok.kind != tokenString {
+			return nil, p.errorf("expected a string in the list, got %s", p.tok)
+		}
+		values = append(values, p.tok.value)
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+	}
+	return &inNode{operand: left, values: values}, p.next()
+}
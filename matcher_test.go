@@ -0,0 +1,130 @@
+package cmdline
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatcher(t *testing.T) {
+	kafka := "java -Xmx1G -Dkafka.logs.dir=/var/log/kafka -Dcom.sun.management.jmxremote -cp /opt/kafka/libs/* kafka.Kafka config/server.properties"
+	nginx := "/usr/sbin/nginx -c /etc/nginx/nginx.conf"
+	celery := "LANG=C python3 -m celery worker --loglevel=INFO"
+
+	tests := []struct {
+		expr     string
+		cmdline  string
+		expected bool
+	}{
+		{expr: `runtime == "java" && java.main =~ "Kafka" && java.props["kafka.logs.dir"] != ""`, cmdline: kafka, expected: true},
+		{expr: `runtime == "java" && java.main =~ "Kafka" && java.props["kafka.logs.dir"] != ""`, cmdline: nginx, expected: false},
+		{expr: `java.props["missing"] == ""`, cmdline: kafka, expected: true},
+		{expr: `java.props["kafka.logs.dir"] == "/var/log/kafka"`, cmdline: kafka, expected: true},
+		{expr: `java.jmx && name == "Kafka"`, cmdline: kafka, expected: true},
+		{expr: `java.jmx`, cmdline: nginx, expected: false},
+		{expr: `exe.base in ("nginx","httpd")`, cmdline: nginx, expected: true},
+		{expr: `exe.base not in ("nginx", "httpd")`, cmdline: nginx, expected: false},
+		{expr: `exe.base in ()`, cmdline: nginx, expected: false},
+		{expr: `"-c" in args && !("--debug" in args)`, cmdline: nginx, expected: true},
+		{expr: `exe =~ "^/usr/s?bin/" && exe !~ "apache"`, cmdline: nginx, expected: true},
+		{expr: `python.module == "celery" && "worker" in python.args && env["LANG"] == "C"`, cmdline: celery, expected: true},
+		{expr: `python.script == "celery" && python.module != ""`, cmdline: celery, expected: true},
+		{expr: `"LANG=C" in env && env`, cmdline: celery, expected: true},
+		{expr: `env`, cmdline: nginx, expected: false},
+		{expr: `runtime == "python" || runtime == "ruby"`, cmdline: celery, expected: true},
+		{expr: `!(runtime == "python") || exe.base == exe`, cmdline: celery, expected: true},
+		{expr: `cmdline =~ "loglevel=INFO"`, cmdline: celery, expected: true},
+		{expr: "name == `nginx` && \"a\\\"b\" != name", cmdline: nginx, expected: true},
+		{expr: `sub.base == "java"`, cmdline: "sudo -u kafka java kafka.Kafka", expected: true},
+	}
+
+	for _, tt := range tests {
+		m, err := CompileMatcher(tt.expr)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		c, err := ParseCommandLine(false, tt.cmdline)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		assert.Equal(t, tt.expected, m.Match(c), tt.expr)
+	}
+}
+
+func TestMatcherFields(t *testing.T) {
+	registry := NewRegistry()
+	if err := registry.LoadRuleFile("testdata/rules/acme.yaml"); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		isWindows bool
+		cmdline   string
+	}{
+		{cmdline: "java -Dcom.sun.management.jmxremote.port=9010 -jar /opt/app.jar"},
+		{cmdline: "java -Xmx1g"},
+		{cmdline: "ruby -w app.rb"},
+		{cmdline: "python3 -m http.server"},
+		{cmdline: "python3 -c 'import app'"},
+		{cmdline: "sudo -u www nginx"},
+		{cmdline: "acme-agent -c etc/acme.yml run"},
+		{isWindows: true, cmdline: `C:\Windows\system32\svchost.exe -k netsvcs -p -s Schedule`},
+		{isWindows: true, cmdline: `rundll32.exe shell32.dll,Control_RunDLL`},
+		{cmdline: ""},
+	}
+
+	// every field is evaluated against the command lines that lack its part
+	for _, tt := range tests {
+		c, err := NewParser(WithFlavor(flavorOf(tt.isWindows)), WithRegistry(registry), WithStrictness(StrictnessLenient)).ParseCommandLine(tt.cmdline)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		for name, field := range matcherFields {
+			assert.NotPanics(t, func() { field(c) }, name+" of "+tt.cmdline)
+		}
+		for name, field := range matcherListFields {
+			assert.NotPanics(t, func() { field(c) }, name+" of "+tt.cmdline)
+		}
+		for name, field := range matcherMapFields {
+			assert.NotPanics(t, func() { field(c, "key") }, name+" of "+tt.cmdline)
+		}
+	}
+}
+
+func TestMatcherErrors(t *testing.T) {
+	tests := []struct {
+		expr   string
+		offset int
+		reason string
+	}{
+		{expr: `java.mian == "x"`, offset: 0, reason: `unknown field "java.mian"`},
+		{expr: `runtime == `, offset: 11, reason: "expected a field or a string, got end of expression"},
+		{expr: `runtime == "java`, offset: 11, reason: "unterminated string"},
+		{expr: `java.main =~ "("`, offset: 13, reason: "invalid regular expression: error parsing regexp: missing closing ): `(`"},
+		{expr: `java.main =~ name`, offset: 13, reason: `expected a regular expression string, got "name"`},
+		{expr: `java.props == "x"`, offset: 0, reason: `map field "java.props" needs a ["key"]`},
+		{expr: `name["x"] == "x"`, offset: 0, reason: `field "name" is not a map`},
+		{expr: `args == "x"`, offset: 5, reason: `unexpected "=="`},
+		{expr: `name in name`, offset: 8, reason: `expected a list or a list field, got "name"`},
+		{expr: `name in ("a" "b")`, offset: 13, reason: `expected ",", got string "b"`},
+		{expr: `(name == "a"`, offset: 12, reason: `expected ")", got end of expression`},
+		{expr: `name not ("a")`, offset: 9, reason: `expected "in" after "not", got "("`},
+		{expr: `name == 'a'`, offset: 8, reason: `unexpected character '\''`},
+	}
+
+	for _, tt := range tests {
+		_, err := CompileMatcher(tt.expr)
+		var matcherErr *MatcherError
+		if !errors.As(err, &matcherErr) {
+			t.Error("[", tt.expr, "] want MatcherError got", err)
+			continue
+		}
+		assert.Equal(t, tt.offset, matcherErr.Offset, tt.expr)
+		assert.Equal(t, tt.reason, matcherErr.Reason, tt.expr)
+	}
+
+	assert.Panics(t, func() { MustCompileMatcher("name ==") })
+}
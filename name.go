@@ -0,0 +1,79 @@
+package cmdline
+
+import (
+	"path"
+	"strings"
+)
+
+// ServiceName guesses the name of the service from the main class, script,
+// wrapped command or windows component, and from the executable otherwise.
+func (c *CommandLine) ServiceName() string {
+	switch {
+	case c.Java != nil:
+		return javaServiceName(c.Java.ClassName)
+	case c.Python != nil:
+		if c.runtimeTerminal() != nil {
+			return c.Python.FilePath
+		}
+		return trimExtension(scriptBase(c.Python.FilePath))
+	case c.Ruby != nil:
+		return trimExtension(scriptBase(c.Ruby.FilePath))
+	case c.Sub != nil:
+		return executableName(c.isWindows(), c.Sub.Command)
+	case c.Windows != nil:
+		if name := windowsServiceName(c.Windows); name != "" {
+			return name
+		}
+	}
+	return executableName(c.isWindows(), c.ExecutePath)
+}
+
+func javaServiceName(className string) string {
+	if strings.HasSuffix(strings.ToLower(className), javaJarExtension) {
+		return trimExtension(scriptBase(className))
+	}
+
+	// take the project name after the package 'org.apache.'
+	if strings.HasPrefix(className, javaApachePrefix) {
+		project := className[len(javaApachePrefix):]
+		if idx := strings.IndexByte(project, '.'); idx > 0 {
+			return project[:idx]
+		}
+		return project
+	}
+
+	if idx := strings.LastIndexByte(className, '.'); idx >= 0 && idx+1 < len(className) {
+		return className[idx+1:]
+	}
+	return className
+}
+
+func windowsServiceName(svc *WindowsService) string {
+	switch {
+	case svc.ServiceName != "":
+		return svc.ServiceName
+	case svc.Group != "":
+		return svc.Group
+	case svc.DLL != "":
+		return trimExtension(scriptBase(svc.DLL))
+	case svc.ProcessID != "":
+		return svc.ProcessID
+	case svc.Package != "":
+		return trimExtension(scriptBase(svc.Package))
+	case svc.Task != "":
+		return svc.Task
+	}
+	return ""
+}
+
+// scripts and jars may come with either kind of path separator
+func scriptBase(s string) string {
+	return path.Base(strings.ReplaceAll(s, "\\", "/"))
+}
+
+func trimExtension(s string) string {
+	if idx := strings.LastIndexByte(s, '.'); idx > 0 {
+		return s[:idx]
+	}
+	return s
+}
@@ -0,0 +1,36 @@
+package cmdline
+
+import (
+	"testing"
+)
+
+func TestServiceName(t *testing.T) {
+	tests := []struct {
+		isWindows bool
+		cmdline   string
+		expected  string
+	}{
+		{cmdline: "./my-server.sh", expected: "my-server.sh"},
+		{cmdline: "/usr/sbin/nginx -g daemon off;", expected: "nginx"},
+		{cmdline: "sudo -E -u dog /usr/local/bin/myApp -items=0,1,2,3", expected: "myApp"},
+		{cmdline: "/opt/python/2.7.11/bin/python2.7 /opt/dogweb/bin/flask run", expected: "flask"},
+		{cmdline: "python3 /srv/app/manage.py runserver", expected: "manage"},
+		{cmdline: "python3 -m http.server 8000", expected: "http.server"},
+		{cmdline: "ruby /usr/sbin/td-agent --daemon", expected: "td-agent"},
+		{cmdline: "java -Xmx4000m -jar /opt/sheepdog/bin/myservice.jar", expected: "myservice"},
+		{cmdline: "java -Xmx4000m com.datadog.example.HelloWorld", expected: "HelloWorld"},
+		{cmdline: "java -cp /etc/cassandra org.apache.cassandra.service.CassandraDaemon", expected: "cassandra"},
+		{cmdline: "java kafka.Kafka", expected: "Kafka"},
+		{isWindows: true, cmdline: `C:\Windows\system32\svchost.exe -k netsvcs -p -s Schedule`, expected: "Schedule"},
+		{isWindows: true, cmdline: `C:\Windows\system32\svchost.exe -k LocalServiceNetworkRestricted`, expected: "LocalServiceNetworkRestricted"},
+		{isWindows: true, cmdline: `rundll32.exe C:\Windows\system32\shell32.dll,Control_RunDLL`, expected: "shell32"},
+		{isWindows: true, cmdline: `D:\app\bin\server.exe --port 80`, expected: "server"},
+	}
+
+	for _, tt := range tests {
+		c, _ := ParseCommandLine(tt.isWindows, tt.cmdline)
+		if name := c.ServiceName(); name != tt.expected {
+			t.Error("[", tt.cmdline, "] want", tt.expected, "got", name)
+		}
+	}
+}
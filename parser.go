@@ -0,0 +1,428 @@
+package cmdline
+
+import (
+	"errors"
+	"io/fs"
+	"log/slog"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"unicode/utf8"
+
+	"github.com/mattn/go-shellwords"
+)
+
+// Flavor is the operating system whose rules split and name the command line
+type Flavor int
+
+const (
+	// FlavorPOSIX splits with the shell rules, the default
+	FlavorPOSIX Flavor = iota
+	// FlavorWindows splits like CommandLineToArgvW and knows the windows
+	// service hosts
+	FlavorWindows
+)
+
+func (f Flavor) String() string {
+	if f == FlavorWindows {
+		return "windows"
+	}
+	return "posix"
+}
+
+// Strictness is what a Parser returns as error besides the hard failures
+type Strictness int
+
+const (
+	// StrictnessDefault returns the problem of the extractor that left the
+	// result partial, like a python without a script file
+	StrictnessDefault Strictness = iota
+	// StrictnessLenient only reports the problems in Diagnostics
+	StrictnessLenient
+	// StrictnessStrict returns the first of the Diagnostics, unknown options
+	// included
+	StrictnessStrict
+)
+
+// Parser parses command lines with the rules given to NewParser. It is safe
+// for concurrent use.
+type Parser struct {
+	flavor     Flavor
+	env        map[string]string
+	workingDir string
+	fsys       fs.FS
+	fsysID     uint64
+	registry   *Registry
+	strictness Strictness
+	maxDepth   int
+	logger     *slog.Logger
+	cache      *Cache
+	cacheKey   string
+	processEnv bool
+	// explain keeps the roles of the arguments, see ParseExplained
+	explain bool
+}
+
+// Option configures a Parser
+type Option func(p *Parser)
+
+// WithFlavor sets the operating system rules, FlavorPOSIX by default
+func WithFlavor(flavor Flavor) Option {
+	return func(p *Parser) {
+		p.flavor = flavor
+	}
+}
+
+// WithEnv sets the environment of the process, e.g. from
+// /proc/<pid>/environ, and expands the references to it in the executable
+// and the arguments: $VAR, ${VAR}, ${VAR:-default} and ${VAR-default}, or
+// %VAR% on windows. The expansion runs on the split arguments, so a
+// reference in single quotes is expanded too. Nothing is expanded by default.
+func WithEnv(env map[string]string) Option {
+	return func(p *Parser) {
+		p.env = env
+	}
+}
+
+// WithWorkingDir sets the directory the process runs in, the relative paths
+// of the command line are resolved with it
+func WithWorkingDir(dir string) Option {
+	return func(p *Parser) {
+		p.workingDir = dir
+	}
+}
+
+// WithFS sets the file system the resolvers look at, for the executables and
+// scripts of the command line. Without it nothing is resolved.
+//
+// When fsys is a ReadLinkFS, like the one of DirFS, the symbolic links of
+// the executables with a directory are followed into Resolved, and the
+// extractor is looked up by the name of the resolved executable first: the
+// /usr/bin/python that leads to /usr/bin/python3.9 is a python 3.9.
+//
+// The executables no extractor knows are read for a "#!" line, a script is
+// parsed as the command line of its interpreter with the script and its
+// arguments, like the kernel runs it, and ViaShebang is set. The
+// interpreters run by env, like "#!/usr/bin/env -S python3 -u", are taken
+// out of the env command.
+func WithFS(fsys fs.FS) Option {
+	id := fsysIDs.Add(1)
+	return func(p *Parser) {
+		p.fsys = fsys
+		p.fsysID = id
+	}
+}
+
+// fsysIDs numbers the file systems of WithFS for the keys of the cache
+var fsysIDs atomic.Uint64
+
+// WithRegistry sets the extractors, the built-in ones by default
+func WithRegistry(registry *Registry) Option {
+	return func(p *Parser) {
+		p.registry = registry
+	}
+}
+
+// WithStrictness sets what is returned as error, StrictnessDefault by default
+func WithStrictness(strictness Strictness) Option {
+	return func(p *Parser) {
+		p.strictness = strictness
+	}
+}
+
+// WithMaxDepth sets how many wrappers deep the commands of wrappers like sudo
+// are parsed into SubCommand.CommandLine. The default 0 doesn't parse them.
+func WithMaxDepth(depth int) Option {
+	return func(p *Parser) {
+		p.maxDepth = depth
+	}
+}
+
+// WithLogger sets the logger that traces the parsing at the debug level:
+// the extractor that matched each executable, the arguments it skipped
+// before the target and why, and the diagnostics. Nothing is logged by
+// default.
+func WithLogger(logger *slog.Logger) Option {
+	return func(p *Parser) {
+		p.logger = logger
+	}
+}
+
+// NewParser returns a parser configured by opts
+func NewParser(opts ...Option) *Parser {
+	p := &Parser{
+		registry: defaultRegistry,
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	if p.registry == nil {
+		p.registry = defaultRegistry
+	}
+	if p.cache != nil {
+		p.cacheKey = p.cacheKeyPrefix()
+	}
+	return p
+}
+
+// the parsers of the package level functions
+var (
+	posixParser   = NewParser()
+	windowsParser = NewParser(WithFlavor(FlavorWindows))
+)
+
+func defaultParser(isWindows bool) *Parser {
+	if isWindows {
+		return windowsParser
+	}
+	return posixParser
+}
+
+func (p *Parser) isWindows() bool {
+	return p.flavor == FlavorWindows
+}
+
+// ParseCommandLine splits s into arguments and parses them. A
+// *TokenizeError is returned without CommandLine, the other errors come with
+// the partial result as Strictness says.
+func (p *Parser) ParseCommandLine(s string) (*CommandLine, error) {
+	if p.cache == nil {
+		return p.parseCommandLine(s)
+	}
+	return p.cache.get(p.cacheKey+"\x00"+s, func() (*CommandLine, error) {
+		return p.parseCommandLine(s)
+	})
+}
+
+func (p *Parser) parseCommandLine(s string) (*CommandLine, error) {
+	if len(s) == 0 {
+		return &CommandLine{}, nil
+	}
+
+	var envs, args []string
+	if p.isWindows() {
+		var err error
+		args, err = SplitWindows(strings.TrimLeft(s, " \t"))
+		if err != nil {
+			return nil, err
+		}
+	} else if plain, ok := splitPlain(s); ok {
+		args = plain
+	} else {
+		sp := shellParsers.Get().(*shellwords.Parser)
+		var err error
+		envs, args, err = sp.ParseWithEnvs(s)
+		shellParsers.Put(sp)
+		if err != nil {
+			return nil, shellTokenizeError(s)
+		}
+	}
+	if len(args) == 0 {
+		return nil, &TokenizeError{Input: s, Offset: len(s), Reason: "no executable"}
+	}
+
+	exe := args[0]
+	// trim any quotes from the executable
+	exe = strings.Trim(exe, "\"")
+	c, err := p.parseArgs(exe, args[1:])
+	if len(envs) > 0 {
+		c.Env = append(envs, c.Env...)
+	}
+	return c, err
+}
+
+// shellParsers are the tokenizers of the command lines splitPlain can't
+// split, they keep no state between the command lines
+var shellParsers = sync.Pool{
+	New: func() interface{} {
+		return shellwords.NewParser()
+	},
+}
+
+// shellSpecial are the bytes shellwords interprets besides the blanks
+const shellSpecial = "\\\"'`();&|<>"
+
+// splitPlain splits the command lines without quotes, escapes nor
+// operators at the blanks like shellwords does, but the arguments are
+// substrings of s. It doesn't take apart the assignments in front of the
+// executable, those are left to shellwords.
+func splitPlain(s string) ([]string, bool) {
+	n, inField, ascii := 0, false, true
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c == ' ' || c == '\t' || c == '\r' || c == '\n' {
+			inField = false
+			continue
+		}
+		if !inField {
+			n++
+			inField = true
+		}
+		switch {
+		case strings.IndexByte(shellSpecial, c) >= 0, c == '=' && n == 1:
+			return nil, false
+		case c >= utf8.RuneSelf:
+			ascii = false
+		}
+	}
+	if !ascii && !utf8.ValidString(s) {
+		return nil, false
+	}
+
+	args := make([]string, 0, n)
+	start := -1
+	for i := 0; i <= len(s); i++ {
+		if i < len(s) && s[i] != ' ' && s[i] != '\t' && s[i] != '\r' && s[i] != '\n' {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		if start >= 0 {
+			args = append(args, s[start:i])
+			start = -1
+		}
+	}
+	return args, true
+}
+
+// Parse parses the executable exe with the arguments args
+func (p *Parser) Parse(exe string, args []string) (*CommandLine, error) {
+	if p.cache == nil {
+		return p.parseArgs(exe, args)
+	}
+	key := p.cacheKey + "\x01" + exe + "\x00" + strings.Join(args, "\x00")
+	return p.cache.get(key, func() (*CommandLine, error) {
+		return p.parseArgs(exe, args)
+	})
+}
+
+func (p *Parser) parseArgs(exe string, args []string) (*CommandLine, error) {
+	var expansions []Expansion
+	if p.env != nil {
+		exe, args, expansions = p.expand(exe, args)
+	}
+
+	c, err := p.parse(0, 0, exe, args)
+	if c.ViaShebang {
+		// the script and its arguments moved behind the interpreter
+		for idx := range expansions {
+			expansions[idx].Index += c.ScriptIndex + 1
+		}
+	}
+	c.Expansions = expansions
+	c.WorkingDir = p.workingDir
+	return c, err
+}
+
+// parse parses exe run with args, shebangs counts the scripts that led to
+// exe as their interpreter
+func (p *Parser) parse(depth, shebangs int, exe string, args []string) (*CommandLine, error) {
+	c := &CommandLine{
+		ExecutePath: exe,
+		Args:        args,
+	}
+
+	name := executableName(p.isWindows(), exe)
+	var contextFn ExtractorFunc
+	if p.fsys != nil {
+		contextFn, name = p.resolve(c, depth, name)
+	}
+	if contextFn == nil {
+		contextFn = p.registry.lookup(p.isWindows(), name)
+	}
+	if contextFn == nil && p.fsys != nil && shebangs < maxShebangs && !p.isWindows() {
+		if sc, err := p.parseScript(depth, shebangs, c); sc != nil {
+			return sc, err
+		}
+	}
+	if contextFn == nil {
+		if p.logger != nil {
+			p.logger.Debug("no extractor", "executable", name, "depth", depth)
+		}
+		c.Runtime = detectRuntime(p.isWindows(), c)
+		return c, p.strict(c, nil)
+	}
+	if p.logger != nil {
+		p.logger.Debug("extractor matched", "executable", name, "depth", depth)
+		c.trace = &tracer{logger: p.logger.With("executable", name, "depth", depth)}
+	}
+	if p.explain {
+		if c.trace == nil {
+			c.trace = &tracer{}
+		}
+		c.trace.tokens = make([]tokenTrace, len(args))
+	}
+	if p.strictness == StrictnessStrict {
+		if c.trace == nil {
+			c.trace = &tracer{}
+		}
+		c.trace.strict = true
+	}
+
+	err := c.diagnose(contextFn(c))
+	c.Runtime = detectRuntime(p.isWindows(), c)
+	if c.trace != nil {
+		if c.trace.logger != nil {
+			for _, d := range c.Diagnostics {
+				c.trace.logger.Debug("diagnostic", "index", d.Index, "error", d.Err)
+			}
+		}
+		// ParseExplained takes the roles of the arguments
+		if !p.explain {
+			c.trace = nil
+		}
+	}
+	if c.Sub != nil && depth < p.maxDepth {
+		var subErr error
+		c.Sub.CommandLine, subErr = p.parse(depth+1, 0, c.Sub.Command, c.Sub.Args)
+		if err == nil {
+			err = subErr
+		}
+	}
+
+	return c, p.strict(c, err)
+}
+
+// strict returns err of the extractor of c as the strictness says
+func (p *Parser) strict(c *CommandLine, err error) error {
+	switch p.strictness {
+	case StrictnessLenient:
+		return nil
+	case StrictnessStrict:
+		if err == nil && len(c.Diagnostics) > 0 {
+			err = c.Diagnostics[0].Err
+		}
+	}
+	return err
+}
+
+// resolve follows the links of the executable of c, and looks up the
+// extractor of the resolved name. The invoked name is kept when no extractor
+// knows the resolved one, the multi-call binaries like busybox run as the
+// name they are invoked with.
+func (p *Parser) resolve(c *CommandLine, depth int, name string) (ExtractorFunc, string) {
+	resolved, err := p.resolveExecutable(c.ExecutePath)
+	if err != nil {
+		if p.logger != nil {
+			p.logger.Debug("executable not resolved", "executable", name, "depth", depth, "error", err)
+		}
+		if errors.Is(err, ErrLinkLoop) {
+			c.Diagnostics = append(c.Diagnostics, Diagnostic{Index: -1, Err: err})
+		}
+		return nil, name
+	}
+	if resolved == nil {
+		return nil, name
+	}
+	c.Resolved = resolved
+	if p.logger != nil {
+		p.logger.Debug("executable resolved", "executable", name, "depth", depth, "path", resolved.Path, "links", len(resolved.Links))
+	}
+
+	resolvedName := executableName(false, resolved.Path)
+	if fn := p.registry.lookup(false, resolvedName); fn != nil {
+		return fn, resolvedName
+	}
+	return nil, name
+}
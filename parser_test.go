@@ -0,0 +1,195 @@
+package cmdline
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/mattn/go-shellwords"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParserStrictness(t *testing.T) {
+	tests := []struct {
+		strictness Strictness
+		cmdline    string
+		expected   error
+	}{
+		{strictness: StrictnessDefault, cmdline: `svchost.exe -k netsvcs -x`},
+		{strictness: StrictnessLenient, cmdline: `svchost.exe -k netsvcs -x`},
+		{strictness: StrictnessStrict, cmdline: `svchost.exe -k netsvcs -x`, expected: &UnknownOptionError{}},
+		{strictness: StrictnessDefault, cmdline: `svchost.exe -x`, expected: ErrNoComponent},
+		{strictness: StrictnessLenient, cmdline: `svchost.exe -x`},
+		{strictness: StrictnessStrict, cmdline: `svchost.exe -x`, expected: ErrNoComponent},
+	}
+
+	for _, tt := range tests {
+		p := NewParser(WithFlavor(FlavorWindows), WithStrictness(tt.strictness))
+		c, err := p.ParseCommandLine(tt.cmdline)
+		if c == nil {
+			t.Error("[", tt.cmdline, "] want a command line got", err)
+			continue
+		}
+
+		var unknown *UnknownOptionError
+		switch {
+		case tt.expected == nil:
+			assert.NoError(t, err, tt.cmdline)
+		case errors.As(tt.expected, &unknown):
+			assert.True(t, errors.As(err, &unknown), tt.cmdline)
+		default:
+			assert.True(t, errors.Is(err, tt.expected), tt.cmdline)
+		}
+	}
+}
+
+func TestParserStrictUnknownOptions(t *testing.T) {
+	strict := NewParser(WithStrictness(StrictnessStrict))
+	for _, tt := range []struct {
+		cmdline string
+		option  string
+		index   int
+	}{
+		{cmdline: "java -frobnicate -jar app.jar", option: "-frobnicate", index: 0},
+		{cmdline: "python3 -u --frobnicate app.py", option: "--frobnicate", index: 1},
+		{cmdline: "ruby --frobnicate app.rb", option: "--frobnicate", index: 0},
+		{cmdline: "sudo -u dog --frobnicate nginx", option: "--frobnicate", index: 2},
+	} {
+		c, err := strict.ParseCommandLine(tt.cmdline)
+		var unknown *UnknownOptionError
+		if assert.True(t, errors.As(err, &unknown), tt.cmdline) {
+			assert.Equal(t, tt.option, unknown.Option, tt.cmdline)
+			assert.Equal(t, tt.index, unknown.Index, tt.cmdline)
+		}
+		assert.Len(t, c.Diagnostics, 1, tt.cmdline)
+
+		// the other strictnesses take them as boolean flags
+		c, err = ParseCommandLine(false, tt.cmdline)
+		assert.NoError(t, err, tt.cmdline)
+		assert.Empty(t, c.Diagnostics, tt.cmdline)
+	}
+
+	_, err := strict.ParseCommandLine("java -Xmx1g -server -jar app.jar")
+	assert.NoError(t, err)
+}
+
+func TestParserRegistry(t *testing.T) {
+	registry := NewRegistry()
+	registry.Register("acme-agent", func(cmdline *CommandLine) error {
+		cmdline.Sub = &SubCommand{Command: cmdline.Args[len(cmdline.Args)-1]}
+		return nil
+	})
+	registry.RegisterWindows("ACME-Service", parseCommandContextSudo)
+
+	c, err := NewParser(WithRegistry(registry)).ParseCommandLine("/opt/acme/acme-agent2.1 --conf x.yml run")
+	assert.NoError(t, err)
+	if assert.NotNil(t, c.Sub) {
+		assert.Equal(t, "run", c.Sub.Command)
+	}
+
+	c, err = NewParser(WithRegistry(registry), WithFlavor(FlavorWindows)).ParseCommandLine(`C:\acme\acme-service.exe worker.exe -v`)
+	assert.NoError(t, err)
+	if assert.NotNil(t, c.Sub) {
+		assert.Equal(t, "worker.exe", c.Sub.Command)
+	}
+
+	// the built-in registry doesn't change
+	c, err = ParseCommandLine(false, "/opt/acme/acme-agent --conf x.yml run")
+	assert.NoError(t, err)
+	assert.Nil(t, c.Sub)
+}
+
+func TestParserMaxDepth(t *testing.T) {
+	s := "sudo -u dog sudo -E java -jar /opt/app.jar"
+
+	c, err := NewParser().ParseCommandLine(s)
+	assert.NoError(t, err)
+	if assert.NotNil(t, c.Sub) {
+		assert.Nil(t, c.Sub.CommandLine)
+	}
+
+	c, err = NewParser(WithMaxDepth(1)).ParseCommandLine(s)
+	assert.NoError(t, err)
+	inner := c.Sub.CommandLine
+	if assert.NotNil(t, inner) {
+		assert.Equal(t, []string{"-E", "java", "-jar", "/opt/app.jar"}, inner.Args)
+		assert.Equal(t, "java", inner.Sub.Command)
+		assert.Nil(t, inner.Sub.CommandLine)
+	}
+
+	c, err = NewParser(WithMaxDepth(2)).ParseCommandLine(s)
+	assert.NoError(t, err)
+	java := c.Sub.CommandLine.Sub.CommandLine
+	if assert.NotNil(t, java) && assert.NotNil(t, java.Java) {
+		assert.Equal(t, "/opt/app.jar", java.Java.ClassName)
+	}
+
+	// the error of the wrapped command is returned
+	_, err = NewParser(WithMaxDepth(2)).ParseCommandLine("sudo -u dog python3 -u")
+	assert.True(t, errors.Is(err, ErrNoScript))
+}
+
+func TestParserLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	_, err := NewParser(WithLogger(logger)).ParseCommandLine("python3 app.py")
+	assert.NoError(t, err)
+	assert.Contains(t, buf.String(), "executable=python3")
+
+	buf.Reset()
+	_, err = NewParser(WithLogger(logger), WithMaxDepth(1)).ParseCommandLine("sudo -u dog -- LANG=C java -Xmx1g --frobnicate -jar app.jar")
+	assert.NoError(t, err)
+	for _, expected := range []string{
+		`msg="extractor matched" executable=sudo depth=0`,
+		`msg="argument skipped" executable=sudo depth=0 index=0 arg=-u reason="wrapper option"`,
+		`index=1 arg=dog reason="option value" option=-u`,
+		`index=2 arg=-- reason="end of options"`,
+		`index=3 arg="LANG=C" reason="environment assignment"`,
+		`msg="argument skipped" executable=java depth=1 index=0 arg=-Xmx1g reason="runtime option"`,
+		`index=1 arg=--frobnicate reason="unknown runtime option"`,
+		`msg="target found" executable=java depth=1 index=3 arg=app.jar reason="main target" option=-jar`,
+	} {
+		assert.Contains(t, buf.String(), expected)
+	}
+
+	buf.Reset()
+	_, err = NewParser(WithLogger(logger), WithFlavor(FlavorWindows)).ParseCommandLine("svchost.exe -x")
+	assert.Error(t, err)
+	assert.Contains(t, buf.String(), `msg=diagnostic executable=svchost depth=0 index=0`)
+}
+
+func TestSplitPlain(t *testing.T) {
+	cmdlines := append([]string{
+		"",
+		" \t",
+		"  app  -x\t--port 80 \r\n",
+		"app --port=80 $HOME ${HOME}",
+		"app --name ünïcode",
+		"app \xff",
+		"LANG=C app",
+		"app 'quoted'",
+		`app "quoted"`,
+		`app es\ caped`,
+		"app > log",
+		"app; app",
+		"app $(date)",
+	}, benchCmdlines...)
+
+	for _, s := range cmdlines {
+		args, ok := splitPlain(s)
+		envs, expected, err := shellwords.NewParser().ParseWithEnvs(s)
+		if !ok {
+			continue
+		}
+		assert.NoError(t, err, s)
+		assert.Empty(t, envs, s)
+		assert.Equal(t, expected, args, s)
+	}
+
+	for _, s := range []string{"LANG=C app", "app 'quoted'", `app es\ caped`, "app; app", "app \xff"} {
+		_, ok := splitPlain(s)
+		assert.False(t, ok, s)
+	}
+}
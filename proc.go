@@ -0,0 +1,183 @@
+package cmdline
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// DefaultProcfs is where procfs is mounted on linux
+const DefaultProcfs = "/proc"
+
+type Process struct {
+	PID  int
+	PPID int
+	// Comm is the name of the executable as the kernel knows it
+	Comm string
+
+	CommandLine *CommandLine
+}
+
+// WithProcessEnv expands the command lines of ReadProcess and
+// ScanProcesses with the environment of each process instead of the one of
+// WithEnv, see ReadEnviron. The processes whose environment can't be read
+// keep the one of WithEnv.
+func WithProcessEnv() Option {
+	return func(p *Parser) {
+		p.processEnv = true
+	}
+}
+
+// ReadProcess is short for NewParser().ReadProcess(root, pid)
+func ReadProcess(root string, pid int) (*Process, error) {
+	return posixParser.ReadProcess(root, pid)
+}
+
+// ReadProcess reads the command line of a process from procfs and parses it
+// with p in the working directory of the process, root is usually
+// DefaultProcfs. The error of Parse is returned together with the process,
+// as Parse does.
+func (p *Parser) ReadProcess(root string, pid int) (*Process, error) {
+	dir := filepath.Join(root, strconv.Itoa(pid))
+
+	data, err := os.ReadFile(filepath.Join(dir, "cmdline"))
+	if err != nil {
+		return nil, err
+	}
+
+	proc := &Process{
+		PID: pid,
+	}
+	if stat, err := os.ReadFile(filepath.Join(dir, "stat")); err == nil {
+		proc.Comm, proc.PPID = parseProcStat(string(stat))
+	}
+
+	argv := splitProcCmdline(data)
+	if len(argv) == 0 {
+		// kernel threads and zombies have no command line
+		proc.CommandLine = &CommandLine{}
+		return proc, nil
+	}
+	proc.CommandLine, err = p.forProcess(dir).Parse(argv[0], argv[1:])
+	return proc, err
+}
+
+// forProcess returns p in the working directory of the process of dir, and
+// with its environment for WithProcessEnv
+func (p *Parser) forProcess(dir string) *Parser {
+	q, changed := *p, false
+	// only readable for the processes of the same user without privileges
+	if cwd, err := os.Readlink(filepath.Join(dir, "cwd")); err == nil && cwd != p.workingDir {
+		q.workingDir, changed = cwd, true
+	}
+	if p.processEnv {
+		if env, err := readEnviron(dir); err == nil {
+			q.env, changed = env, true
+		}
+	}
+	if !changed {
+		return p
+	}
+	if q.cache != nil {
+		q.cacheKey = q.cacheKeyPrefix()
+	}
+	return &q
+}
+
+// ReadEnviron reads the environment a process started with from procfs, to
+// expand the command line with WithEnv. Reading the environment of the
+// processes of other users needs privileges.
+func ReadEnviron(root string, pid int) (map[string]string, error) {
+	return readEnviron(filepath.Join(root, strconv.Itoa(pid)))
+}
+
+func readEnviron(dir string) (map[string]string, error) {
+	data, err := os.ReadFile(filepath.Join(dir, "environ"))
+	if err != nil {
+		return nil, err
+	}
+
+	env := map[string]string{}
+	for _, e := range splitProcCmdline(data) {
+		if name, value, ok := strings.Cut(e, "="); ok && name != "" {
+			env[name] = value
+		}
+	}
+	return env, nil
+}
+
+// ScanProcesses is short for NewParser().ScanProcesses(root)
+func ScanProcesses(root string) ([]*Process, error) {
+	return posixParser.ScanProcesses(root)
+}
+
+// ScanProcesses reads all the processes in procfs and parses them with p,
+// sorted by pid. Processes that exit while scanning and kernel threads are
+// left out. The parse errors of a process are left in the Diagnostics of its
+// CommandLine, a python REPL is no failure of the scan. The returned error
+// joins the failures to read procfs, the processes are returned anyway.
+func (p *Parser) ScanProcesses(root string) ([]*Process, error) {
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		processes []*Process
+		errs      []error
+	)
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil || !entry.IsDir() {
+			continue
+		}
+
+		proc, err := p.ReadProcess(root, pid)
+		if proc == nil {
+			// the process exited since the directory was listed
+			if !errors.Is(err, fs.ErrNotExist) && !errors.Is(err, syscall.ESRCH) {
+				errs = append(errs, err)
+			}
+			continue
+		}
+		if proc.CommandLine.ExecutePath == "" {
+			continue
+		}
+		processes = append(processes, proc)
+	}
+
+	sort.Slice(processes, func(i, j int) bool {
+		return processes[i].PID < processes[j].PID
+	})
+	return processes, errors.Join(errs...)
+}
+
+// the arguments are separated and terminated by NUL
+func splitProcCmdline(data []byte) []string {
+	s := strings.TrimRight(string(data), "\x00")
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, "\x00")
+}
+
+// "pid (comm) state ppid ...", comm may hold spaces and parentheses
+func parseProcStat(stat string) (string, int) {
+	start := strings.IndexByte(stat, '(')
+	end := strings.LastIndexByte(stat, ')')
+	if start < 0 || end < start {
+		return "", 0
+	}
+
+	fields := strings.Fields(stat[end+1:])
+	if len(fields) < 2 {
+		return stat[start+1 : end], 0
+	}
+	ppid, _ := strconv.Atoi(fields[1])
+	return stat[start+1 : end], ppid
+}
@@ -0,0 +1,172 @@
+package cmdline
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// writeProcfs makes a fake procfs with the given cmdline files
+func writeProcfs(t *testing.T, processes map[int][2]string) string {
+	t.Helper()
+
+	root := t.TempDir()
+	for pid, files := range processes {
+		dir := filepath.Join(root, strconv.Itoa(pid))
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "cmdline"), []byte(files[0]), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "stat"), []byte(files[1]), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// not a process
+	if err := os.MkdirAll(filepath.Join(root, "sys"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return root
+}
+
+func TestScanProcesses(t *testing.T) {
+	root := writeProcfs(t, map[int][2]string{
+		1:   {"/sbin/init\x00splash\x00", "1 (systemd) S 0 1 1 0 -1"},
+		2:   {"", "2 (kthreadd) S 0 0 0 0 -1"},
+		100: {"java\x00-Xmx1g\x00-jar\x00/opt/my app/app.jar\x00", "100 (java) S 1 100 100 0 -1"},
+		101: {"python3\x00", "101 (my (odd) name) S 100 100 100 0 -1"},
+	})
+
+	processes, err := ScanProcesses(root)
+	assert.NoError(t, err)
+	if len(processes) != 3 {
+		t.Fatal("want 3 processes got", len(processes))
+	}
+
+	assert.Equal(t, 1, processes[0].PID)
+	assert.Equal(t, 0, processes[0].PPID)
+	assert.Equal(t, "systemd", processes[0].Comm)
+	assert.Equal(t, []string{"/sbin/init", "splash"}, processes[0].CommandLine.Argv())
+
+	assert.Equal(t, 100, processes[1].PID)
+	assert.Equal(t, 1, processes[1].PPID)
+	assert.Equal(t, "/opt/my app/app.jar", processes[1].CommandLine.Java.ClassName)
+
+	assert.Equal(t, "my (odd) name", processes[2].Comm)
+	assert.Equal(t, 100, processes[2].PPID)
+	// the python REPL is kept with its diagnostic
+	if assert.Len(t, processes[2].CommandLine.Diagnostics, 1) {
+		assert.ErrorIs(t, processes[2].CommandLine.Diagnostics[0].Err, ErrNoScript)
+	}
+
+	p, err := ReadProcess(root, 2)
+	if err != nil {
+		t.Error(err)
+	}
+	assert.Equal(t, &CommandLine{}, p.CommandLine)
+
+	if _, err := ReadProcess(root, 3); err == nil {
+		t.Error("want error got ok")
+	}
+
+	// a procfs that can't be read fails the scan
+	if err := os.Mkdir(filepath.Join(root, "4"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(root, "4", "cmdline"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	processes, err = ScanProcesses(root)
+	assert.Error(t, err)
+	assert.Len(t, processes, 3)
+}
+
+func TestReadEnviron(t *testing.T) {
+	root := writeProcfs(t, map[int][2]string{
+		100: {"java\x00-jar\x00$APP_HOME/app.jar\x00", "100 (java) S 1 100 100 0 -1"},
+	})
+	environ := "APP_HOME=/opt/app\x00EMPTY=\x00OPTS=-Da=b\x00broken\x00"
+	if err := os.WriteFile(filepath.Join(root, "100", "environ"), []byte(environ), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	env, err := ReadEnviron(root, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, map[string]string{"APP_HOME": "/opt/app", "EMPTY": "", "OPTS": "-Da=b"}, env)
+
+	c, err := NewParser(WithEnv(env)).Parse("java", []string{"-jar", "$APP_HOME/app.jar"})
+	assert.NoError(t, err)
+	assert.Equal(t, "/opt/app/app.jar", c.Java.ClassName)
+
+	if _, err := ReadEnviron(root, 101); err == nil {
+		t.Error("want error got ok")
+	}
+}
+
+func TestReadProcessWorkingDir(t *testing.T) {
+	root := writeProcfs(t, map[int][2]string{
+		100: {"nginx\x00-c\x00conf/nginx.conf\x00", "100 (nginx) S 1 100 100 0 -1"},
+	})
+	if err := os.Symlink("/etc/nginx", filepath.Join(root, "100", "cwd")); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := ReadProcess(root, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "/etc/nginx", p.CommandLine.WorkingDir)
+	assert.Equal(t, []FileRef{
+		{Kind: FileConfig, Path: "conf/nginx.conf", Resolved: "/etc/nginx/conf/nginx.conf", Index: 1},
+	}, p.CommandLine.Files())
+}
+
+func TestParserScanProcesses(t *testing.T) {
+	root := writeProcfs(t, map[int][2]string{
+		100: {"java\x00-jar\x00$APP_HOME/app.jar\x00", "100 (java) S 1 100 100 0 -1"},
+		101: {"acme-agent\x00--conf\x00x.yml\x00run\x00", "101 (acme-agent) S 1 101 101 0 -1"},
+		102: {"./run.sh\x00--port\x0080\x00", "102 (run.sh) S 1 102 102 0 -1"},
+	})
+	if err := os.WriteFile(filepath.Join(root, "100", "environ"), []byte("APP_HOME=/opt/app\x00"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("/srv/app", filepath.Join(root, "102", "cwd")); err != nil {
+		t.Fatal(err)
+	}
+
+	registry := NewRegistry()
+	assert.NoError(t, registry.LoadRuleFile("testdata/rules/acme.yaml"))
+	fsys := fstest.MapFS{"srv/app/run.sh": script("#!/bin/sh -e")}
+	cache := NewCache(10)
+	p := NewParser(WithRegistry(registry), WithFS(fsys), WithProcessEnv(), WithCache(cache))
+
+	processes, err := p.ScanProcesses(root)
+	assert.NoError(t, err)
+	if assert.Len(t, processes, 3) {
+		assert.Equal(t, "/opt/app/app.jar", processes[0].CommandLine.Java.ClassName)
+		if assert.NotNil(t, processes[1].CommandLine.Custom) {
+			assert.Equal(t, "acme-agent", processes[1].CommandLine.Custom.Rule)
+		}
+		// the script is found in the working directory of the process
+		assert.Equal(t, "/bin/sh", processes[2].CommandLine.ExecutePath)
+		assert.True(t, processes[2].CommandLine.ViaShebang)
+		assert.Equal(t, "/srv/app", processes[2].CommandLine.WorkingDir)
+	}
+
+	// the processes are parsed again from the cache
+	_, err = p.ScanProcesses(root)
+	assert.NoError(t, err)
+	assert.Equal(t, CacheStats{Hits: 3, Misses: 3, Entries: 3}, cache.Stats())
+
+	// the environment of the process is only used with WithProcessEnv
+	proc, err := NewParser().ReadProcess(root, 100)
+	assert.NoError(t, err)
+	assert.Equal(t, "$APP_HOME/app.jar", proc.CommandLine.Java.ClassName)
+}
@@ -0,0 +1,292 @@
+package cmdline
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+type QuoteStyle int
+
+const (
+	// QuotePOSIX quotes for sh with single quotes
+	QuotePOSIX QuoteStyle = iota
+	// QuoteBash is QuotePOSIX, except that arguments with control
+	// characters are written as $'...'
+	QuoteBash
+	// QuoteCmd quotes the way CommandLineToArgvW splits, for the command
+	// line of CreateProcess. It is not safe to type in cmd.exe, which takes
+	// no \" as an escape and expands %VAR% in double quotes, use QuoteCmdExe
+	// there.
+	QuoteCmd
+	// QuotePowerShell quotes for the call operator of PowerShell 7.3+,
+	// e.g. & 'C:\Program Files\app.exe' 'a b'
+	QuotePowerShell
+	// QuoteCmdExe is QuoteCmd with the cmd.exe metacharacters, the double
+	// quotes included, escaped with a caret, for the interactive prompt of
+	// cmd.exe with delayed expansion off. cmd.exe can't pass on the line
+	// breaks, and %VAR% is still expanded in a program name with a space.
+	QuoteCmdExe
+)
+
+func (style QuoteStyle) String() string {
+	switch style {
+	case QuotePOSIX:
+		return "posix"
+	case QuoteBash:
+		return "bash"
+	case QuoteCmd:
+		return "cmd"
+	case QuotePowerShell:
+		return "powershell"
+	case QuoteCmdExe:
+		return "cmdexe"
+	default:
+		return "QuoteStyle(" + strconv.Itoa(int(style)) + ")"
+	}
+}
+
+// Argv returns the executable followed by its arguments.
+func (c *CommandLine) Argv() []string {
+	if c.ExecutePath == "" && len(c.Args) == 0 {
+		return []string{}
+	}
+	argv := make([]string, 0, len(c.Args)+1)
+	argv = append(argv, c.ExecutePath)
+	return append(argv, c.Args...)
+}
+
+// String renders the command line back to a string in the given style.
+//
+// For QuotePOSIX and QuoteCmd, ParseCommandLine(style == QuoteCmd, c.String(style))
+// returns a CommandLine equal to c, provided the executable neither is an
+// environment assignment (one '=') nor starts or ends with a double quote,
+// and for QuoteCmd contains no double quote at all. QuoteBash round-trips the
+// same way unless an argument has control characters. The Env assignments,
+// with only their values quoted, are written in front for QuotePOSIX and
+// QuoteBash only, the other shells have no such syntax.
+func (c *CommandLine) String(style QuoteStyle) string {
+	argv := c.Argv()
+
+	var sb strings.Builder
+	if style == QuotePOSIX || style == QuoteBash {
+		quote := quotePOSIX
+		if style == QuoteBash {
+			quote = quoteBash
+		}
+		for _, e := range c.Env {
+			// only the value is quoted, a quoted name is no assignment
+			if name, value, ok := strings.Cut(e, "="); ok && isShellName(name) {
+				sb.WriteString(name)
+				sb.WriteByte('=')
+				sb.WriteString(quote(value))
+			} else {
+				sb.WriteString(quote(e))
+			}
+			sb.WriteByte(' ')
+		}
+	}
+	for idx, a := range argv {
+		if idx > 0 {
+			sb.WriteByte(' ')
+		}
+
+		switch style {
+		case QuoteBash:
+			sb.WriteString(quoteBash(a))
+		case QuoteCmd:
+			if idx == 0 {
+				sb.WriteString(quoteCmdProgram(a))
+			} else {
+				sb.WriteString(quoteCmd(a))
+			}
+		case QuoteCmdExe:
+			if idx == 0 {
+				sb.WriteString(quoteCmdExeProgram(a))
+			} else {
+				sb.WriteString(escapeCmdExe(quoteCmd(a)))
+			}
+		case QuotePowerShell:
+			if idx == 0 {
+				sb.WriteString("& ")
+				sb.WriteString(quotePowerShellString(a))
+			} else {
+				sb.WriteString(quotePowerShell(a))
+			}
+		default:
+			sb.WriteString(quotePOSIX(a))
+		}
+	}
+	return sb.String()
+}
+
+// isShellName tells if s is a variable name of the shell
+func isShellName(s string) bool {
+	if s == "" || s[0] >= '0' && s[0] <= '9' {
+		return false
+	}
+	for _, c := range []byte(s) {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_') {
+			return false
+		}
+	}
+	return true
+}
+
+func isPOSIXSafe(r rune) bool {
+	return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' ||
+		strings.ContainsRune("_@%+=:,./-", r)
+}
+
+func quotePOSIX(s string) string {
+	if s == "" {
+		return "''"
+	}
+	if strings.IndexFunc(s, func(r rune) bool { return !isPOSIXSafe(r) }) < 0 {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+func quoteBash(s string) string {
+	hasControl := !utf8.ValidString(s) || strings.IndexFunc(s, func(r rune) bool {
+		return r < 0x20 || r == 0x7f
+	}) >= 0
+	if !hasControl {
+		return quotePOSIX(s)
+	}
+
+	var sb strings.Builder
+	sb.WriteString("$'")
+	for idx := 0; idx < len(s); idx++ {
+		c := s[idx]
+		switch c {
+		case '\a':
+			sb.WriteString(`\a`)
+		case '\b':
+			sb.WriteString(`\b`)
+		case '\t':
+			sb.WriteString(`\t`)
+		case '\n':
+			sb.WriteString(`\n`)
+		case '\v':
+			sb.WriteString(`\v`)
+		case '\f':
+			sb.WriteString(`\f`)
+		case '\r':
+			sb.WriteString(`\r`)
+		case '\\':
+			sb.WriteString(`\\`)
+		case '\'':
+			sb.WriteString(`\'`)
+		default:
+			if c < 0x20 || c == 0x7f {
+				sb.WriteString(`\x`)
+				sb.WriteString(strconv.FormatInt(int64(c)|0x100, 16)[1:])
+				continue
+			}
+
+			// copy utf8 sequences as is, escape bytes that are not valid utf8
+			r, size := utf8.DecodeRuneInString(s[idx:])
+			if r == utf8.RuneError && size <= 1 {
+				sb.WriteString(`\x`)
+				sb.WriteString(strconv.FormatInt(int64(c)|0x100, 16)[1:])
+				continue
+			}
+			sb.WriteString(s[idx : idx+size])
+			idx += size - 1
+		}
+	}
+	sb.WriteString("'")
+	return sb.String()
+}
+
+const cmdMetaChars = "&|<>^()%!"
+
+// escapeCmdExe puts a caret before the metacharacters of cmd.exe in s, the
+// double quotes too, so that cmd.exe never enters its quoted mode where the
+// carets are literal
+func escapeCmdExe(s string) string {
+	if !strings.ContainsAny(s, `"`+cmdMetaChars) {
+		return s
+	}
+	var sb strings.Builder
+	for idx := 0; idx < len(s); idx++ {
+		if strings.IndexByte(`"`+cmdMetaChars, s[idx]) >= 0 {
+			sb.WriteByte('^')
+		}
+		sb.WriteByte(s[idx])
+	}
+	return sb.String()
+}
+
+// cmd.exe takes the program name up to the first space outside its quotes,
+// so a name with a space keeps plain double quotes
+func quoteCmdExeProgram(s string) string {
+	if s == "" || strings.ContainsAny(s, " \t") {
+		return `"` + s + `"`
+	}
+	return escapeCmdExe(s)
+}
+
+// the program name can't hold a double quote, it is taken up to the next one
+func quoteCmdProgram(s string) string {
+	if s != "" && !strings.ContainsAny(s, " \t"+cmdMetaChars) {
+		return s
+	}
+	return `"` + s + `"`
+}
+
+// quoteCmd is the inverse of readWindowsArg
+func quoteCmd(s string) string {
+	if s != "" && !strings.ContainsAny(s, " \t\n\v\""+cmdMetaChars) {
+		return s
+	}
+
+	var sb strings.Builder
+	sb.WriteByte('"')
+	nslashes := 0
+	for idx := 0; idx < len(s); idx++ {
+		c := s[idx]
+		switch c {
+		case '\\':
+			nslashes++
+			continue
+		case '"':
+			sb.WriteString(strings.Repeat(`\`, nslashes*2+1))
+		default:
+			sb.WriteString(strings.Repeat(`\`, nslashes))
+		}
+		nslashes = 0
+		sb.WriteByte(c)
+	}
+	// backslashes before the closing quote must be doubled
+	sb.WriteString(strings.Repeat(`\`, nslashes*2))
+	sb.WriteByte('"')
+	return sb.String()
+}
+
+func isPowerShellSafe(r rune) bool {
+	return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' ||
+		strings.ContainsRune(`_./\:`, r)
+}
+
+func quotePowerShell(s string) string {
+	if s != "" && strings.IndexFunc(s, func(r rune) bool { return !isPowerShellSafe(r) }) < 0 {
+		return s
+	}
+	return quotePowerShellString(s)
+}
+
+// powershell also takes the typographic single quotes as quotes
+var powerShellQuoteReplacer = strings.NewReplacer(
+	"'", "''",
+	"\u2018", "\u2018\u2018",
+	"\u2019", "\u2019\u2019",
+	"\u201a", "\u201a\u201a",
+	"\u201b", "\u201b\u201b",
+)
+
+func quotePowerShellString(s string) string {
+	return "'" + powerShellQuoteReplacer.Replace(s) + "'"
+}
@@ -0,0 +1,447 @@
+package cmdline
+
+import (
+	"math/rand"
+	"os/exec"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"testing/quick"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCommandLineString(t *testing.T) {
+	tests := []struct {
+		name     string
+		argv     []string
+		expected map[QuoteStyle]string
+	}{
+		{
+			name: "empty",
+			argv: []string{},
+			expected: map[QuoteStyle]string{
+				QuotePOSIX:      "",
+				QuoteBash:       "",
+				QuoteCmd:        "",
+				QuoteCmdExe:     "",
+				QuotePowerShell: "",
+			},
+		},
+		{
+			name: "plain",
+			argv: []string{"java", "-Xmx4g", "-jar", "/opt/app.jar"},
+			expected: map[QuoteStyle]string{
+				QuotePOSIX:      "java -Xmx4g -jar /opt/app.jar",
+				QuoteBash:       "java -Xmx4g -jar /opt/app.jar",
+				QuoteCmd:        "java -Xmx4g -jar /opt/app.jar",
+				QuoteCmdExe:     "java -Xmx4g -jar /opt/app.jar",
+				QuotePowerShell: "& 'java' '-Xmx4g' '-jar' /opt/app.jar",
+			},
+		},
+		{
+			name: "spaces and quotes",
+			argv: []string{"/home/dd/my java dir/java", "-Dname=it's", "a \"b\""},
+			expected: map[QuoteStyle]string{
+				QuotePOSIX:      `'/home/dd/my java dir/java' '-Dname=it'\''s' 'a "b"'`,
+				QuoteBash:       `'/home/dd/my java dir/java' '-Dname=it'\''s' 'a "b"'`,
+				QuoteCmd:        `"/home/dd/my java dir/java" -Dname=it's "a \"b\""`,
+				QuoteCmdExe:     `"/home/dd/my java dir/java" -Dname=it's ^"a \^"b\^"^"`,
+				QuotePowerShell: `& '/home/dd/my java dir/java' '-Dname=it''s' 'a "b"'`,
+			},
+		},
+		{
+			name: "windows path with trailing backslash",
+			argv: []string{`C:\Program Files\App\app.exe`, `--dir`, `C:\data dir\`, `a\\"b`, ``},
+			expected: map[QuoteStyle]string{
+				QuotePOSIX:      `'C:\Program Files\App\app.exe' --dir 'C:\data dir\' 'a\\"b' ''`,
+				QuoteBash:       `'C:\Program Files\App\app.exe' --dir 'C:\data dir\' 'a\\"b' ''`,
+				QuoteCmd:        `"C:\Program Files\App\app.exe" --dir "C:\data dir\\" "a\\\\\"b" ""`,
+				QuoteCmdExe:     `"C:\Program Files\App\app.exe" --dir ^"C:\data dir\\^" ^"a\\\\\^"b^" ^"^"`,
+				QuotePowerShell: `& 'C:\Program Files\App\app.exe' '--dir' 'C:\data dir\' 'a\\"b' ''`,
+			},
+		},
+		{
+			name: "control characters",
+			argv: []string{"printf", "a\nb\tc'\x01"},
+			expected: map[QuoteStyle]string{
+				QuotePOSIX:      "printf 'a\nb\tc'\\''\x01'",
+				QuoteBash:       `printf $'a\nb\tc\'\x01'`,
+				QuoteCmd:        "printf \"a\nb\tc'\x01\"",
+				QuoteCmdExe:     "printf ^\"a\nb\tc'\x01^\"",
+				QuotePowerShell: "& 'printf' 'a\nb\tc''\x01'",
+			},
+		},
+		{
+			name: "shell metacharacters",
+			argv: []string{"sh", "-c", "a|b&c>d;$(e)%f%"},
+			expected: map[QuoteStyle]string{
+				QuotePOSIX:      `sh -c 'a|b&c>d;$(e)%f%'`,
+				QuoteBash:       `sh -c 'a|b&c>d;$(e)%f%'`,
+				QuoteCmd:        `sh -c "a|b&c>d;$(e)%f%"`,
+				QuoteCmdExe:     `sh -c ^"a^|b^&c^>d;$^(e^)^%f^%^"`,
+				QuotePowerShell: `& 'sh' '-c' 'a|b&c>d;$(e)%f%'`,
+			},
+		},
+		{
+			// cmd.exe takes no \" as an escape, & would run calc
+			name: "cmd.exe injection",
+			argv: []string{"a&b.exe", `a"&calc`, "%PATH%", "!x!"},
+			expected: map[QuoteStyle]string{
+				QuotePOSIX:      `'a&b.exe' 'a"&calc' %PATH% '!x!'`,
+				QuoteCmd:        `"a&b.exe" "a\"&calc" "%PATH%" "!x!"`,
+				QuoteCmdExe:     `a^&b.exe ^"a\^"^&calc^" ^"^%PATH^%^" ^"^!x^!^"`,
+				QuotePowerShell: `& 'a&b.exe' 'a"&calc' '%PATH%' '!x!'`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &CommandLine{}
+			if len(tt.argv) > 0 {
+				c.ExecutePath = tt.argv[0]
+				c.Args = tt.argv[1:]
+			}
+			assert.Equal(t, tt.argv, c.Argv())
+
+			for style, expected := range tt.expected {
+				assert.Equal(t, expected, c.String(style), style.String())
+			}
+		})
+	}
+}
+
+// tricky characters for both shells
+const quoteAlphabet = "ab-_=./\\:\"' \t\n$`;&|<>()%!^*?~#{}[]\x01\u00e9\u4e2d\u2019"
+
+type randomArgv []string
+
+func (randomArgv) Generate(r *rand.Rand, size int) reflect.Value {
+	randomString := func(min int) string {
+		n := min + r.Intn(size+1)
+		var sb strings.Builder
+		runes := []rune(quoteAlphabet)
+		for i := 0; i < n; i++ {
+			sb.WriteRune(runes[r.Intn(len(runes))])
+		}
+		return sb.String()
+	}
+
+	// known executables so that the extractors run too
+	exes := []string{"java", "python3", "ruby", "sudo", "svchost.exe", "/usr/bin/java"}
+	argv := randomArgv{exes[r.Intn(len(exes))]}
+	if r.Intn(2) == 0 {
+		argv[0] = randomString(1)
+	}
+	for n := r.Intn(6); n > 0; n-- {
+		argv = append(argv, randomString(0))
+	}
+	return reflect.ValueOf(argv)
+}
+
+func testRoundTrip(t *testing.T, style QuoteStyle, valid func(argv randomArgv) bool) {
+	isWindows := style == QuoteCmd || style == QuoteCmdExe
+	f := func(argv randomArgv) bool {
+		if !valid(argv) {
+			return true
+		}
+
+		expected, expectedErr := Parse(isWindows, argv[0], append([]string{}, argv[1:]...))
+		s := expected.String(style)
+		if style == QuoteCmdExe {
+			line, ok := cmdExe(s)
+			if !ok {
+				t.Logf("%q -> %q: cmd.exe runs more", []string(argv), s)
+				return false
+			}
+			s = line
+		}
+		actual, err := ParseCommandLine(isWindows, s)
+		if actual == nil {
+			t.Logf("%q -> %q: %v", []string(argv), s, err)
+			return false
+		}
+		if !reflect.DeepEqual(expected, actual) || !reflect.DeepEqual(expectedErr, err) {
+			t.Logf("%q -> %q -> %q", []string(argv), s, actual.Argv())
+			return false
+		}
+		return true
+	}
+	if err := quick.Check(f, &quick.Config{MaxCount: 2000}); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestStringRoundTripPOSIX(t *testing.T) {
+	testRoundTrip(t, QuotePOSIX, func(argv randomArgv) bool {
+		return strings.Count(argv[0], "=") != 1 && strings.Trim(argv[0], "\"") == argv[0]
+	})
+}
+
+func TestStringRoundTripBash(t *testing.T) {
+	testRoundTrip(t, QuoteBash, func(argv randomArgv) bool {
+		for _, a := range argv {
+			if strings.ContainsAny(a, "\x01\t\n") {
+				return false
+			}
+		}
+		return strings.Count(argv[0], "=") != 1 && strings.Trim(argv[0], "\"") == argv[0]
+	})
+}
+
+func TestStringRoundTripCmd(t *testing.T) {
+	testRoundTrip(t, QuoteCmd, func(argv randomArgv) bool {
+		return !strings.ContainsRune(argv[0], '"')
+	})
+}
+
+func TestStringRoundTripCmdExe(t *testing.T) {
+	testRoundTrip(t, QuoteCmdExe, func(argv randomArgv) bool {
+		for _, a := range argv {
+			if strings.ContainsRune(a, '\n') {
+				return false
+			}
+		}
+		if strings.ContainsAny(argv[0], " \t") && strings.ContainsRune(argv[0], '%') {
+			return false
+		}
+		return !strings.ContainsRune(argv[0], '"')
+	})
+}
+
+// cmdExe does to s what the prompt of cmd.exe does before it runs it: the
+// %VAR% are expanded, every variable is set but the names ending with a
+// caret, and the carets outside quotes escape the next character. ok is
+// false when s would run more than one command or redirect.
+func cmdExe(s string) (string, bool) {
+	for idx := 0; idx < len(s); idx++ {
+		if s[idx] != '%' {
+			continue
+		}
+		if end := strings.IndexByte(s[idx+1:], '%'); end > 0 && !strings.HasSuffix(s[idx+1:idx+1+end], "^") {
+			return "", false
+		}
+	}
+
+	var sb strings.Builder
+	inQuote := false
+	for idx := 0; idx < len(s); idx++ {
+		c := s[idx]
+		switch {
+		case c == '\n':
+			return "", false
+		case c == '"':
+			inQuote = !inQuote
+		case inQuote:
+		case c == '^':
+			idx++
+			if idx == len(s) {
+				return sb.String(), true
+			}
+			c = s[idx]
+		case strings.IndexByte("&|<>()", c) >= 0:
+			return "", false
+		}
+		sb.WriteByte(c)
+	}
+	return sb.String(), true
+}
+
+func testSplitRoundTrip(t *testing.T, style QuoteStyle, split func(string) ([]string, bool)) {
+	f := func(argv randomArgv) bool {
+		c := &CommandLine{ExecutePath: argv[0], Args: argv[1:]}
+		s := c.String(style)
+		actual, ok := split(s)
+		if !ok || !reflect.DeepEqual([]string(argv), actual) {
+			t.Logf("%q -> %q -> %q", []string(argv), s, actual)
+			return false
+		}
+		return true
+	}
+	if err := quick.Check(f, &quick.Config{MaxCount: 2000}); err != nil {
+		t.Error(err)
+	}
+}
+
+// the control characters are written as $'...'
+func TestStringRoundTripBashEscapes(t *testing.T) {
+	testSplitRoundTrip(t, QuoteBash, splitBash)
+
+	c := &CommandLine{ExecutePath: "printf", Args: []string{"\xff\x7f", "\u00e9\n"}}
+	words, ok := splitBash(c.String(QuoteBash))
+	assert.True(t, ok)
+	assert.Equal(t, c.Argv(), words)
+}
+
+func TestStringRoundTripPowerShell(t *testing.T) {
+	testSplitRoundTrip(t, QuotePowerShell, splitPowerShell)
+}
+
+// splitBash splits s like bash does, for the words QuoteBash writes: bare
+// words, '...', $'...' with its escapes and \' between them
+func splitBash(s string) ([]string, bool) {
+	var (
+		words  []string
+		word   strings.Builder
+		inWord bool
+	)
+	for idx := 0; idx < len(s); idx++ {
+		c := s[idx]
+		switch {
+		case c == ' ':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+			continue
+		case strings.HasPrefix(s[idx:], "$'"):
+			for idx += 2; idx < len(s) && s[idx] != '\''; idx++ {
+				if s[idx] != '\\' {
+					word.WriteByte(s[idx])
+					continue
+				}
+				if idx++; idx == len(s) {
+					return nil, false
+				}
+				switch e := s[idx]; e {
+				case 'a':
+					word.WriteByte('\a')
+				case 'b':
+					word.WriteByte('\b')
+				case 't':
+					word.WriteByte('\t')
+				case 'n':
+					word.WriteByte('\n')
+				case 'v':
+					word.WriteByte('\v')
+				case 'f':
+					word.WriteByte('\f')
+				case 'r':
+					word.WriteByte('\r')
+				case '\\', '\'':
+					word.WriteByte(e)
+				case 'x':
+					if idx+2 >= len(s) {
+						return nil, false
+					}
+					b, err := strconv.ParseUint(s[idx+1:idx+3], 16, 8)
+					if err != nil {
+						return nil, false
+					}
+					word.WriteByte(byte(b))
+					idx += 2
+				default:
+					return nil, false
+				}
+			}
+			if idx == len(s) {
+				return nil, false
+			}
+		case c == '\'':
+			end := strings.IndexByte(s[idx+1:], '\'')
+			if end < 0 {
+				return nil, false
+			}
+			word.WriteString(s[idx+1 : idx+1+end])
+			idx += end + 1
+		case c == '\\' && idx+1 < len(s):
+			idx++
+			word.WriteByte(s[idx])
+		case isPOSIXSafe(rune(c)):
+			word.WriteByte(c)
+		default:
+			return nil, false
+		}
+		inWord = true
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, true
+}
+
+func isPowerShellQuote(r rune) bool {
+	return r == '\'' || r >= '\u2018' && r <= '\u201b'
+}
+
+// splitPowerShell splits s like PowerShell does, for the words
+// QuotePowerShell writes: the call operator, bare words and '...' where
+// any single quote, the typographic ones too, ends the string unless it
+// is doubled
+func splitPowerShell(s string) ([]string, bool) {
+	s, ok := strings.CutPrefix(s, "& ")
+	if !ok {
+		return nil, false
+	}
+
+	words := []string{}
+	for s != "" {
+		r, size := utf8.DecodeRuneInString(s)
+		if !isPowerShellQuote(r) {
+			word, rest, _ := strings.Cut(s, " ")
+			if strings.IndexFunc(word, func(r rune) bool { return !isPowerShellSafe(r) }) >= 0 {
+				return nil, false
+			}
+			words = append(words, word)
+			s = rest
+			continue
+		}
+
+		var word strings.Builder
+		for s = s[size:]; ; {
+			if s == "" {
+				return nil, false
+			}
+			r, size = utf8.DecodeRuneInString(s)
+			s = s[size:]
+			if !isPowerShellQuote(r) {
+				word.WriteRune(r)
+				continue
+			}
+			if next, n := utf8.DecodeRuneInString(s); n > 0 && isPowerShellQuote(next) {
+				word.WriteRune(r)
+				s = s[n:]
+				continue
+			}
+			break
+		}
+		words = append(words, word.String())
+		if s != "" {
+			if s, ok = strings.CutPrefix(s, " "); !ok {
+				return nil, false
+			}
+		}
+	}
+	return words, true
+}
+
+func TestStringEnvShell(t *testing.T) {
+	c := &CommandLine{Env: []string{"FOO=a b"}, ExecutePath: "printenv", Args: []string{"FOO"}}
+	assert.Equal(t, "FOO='a b' printenv FOO", c.String(QuotePOSIX))
+
+	// the shells keep the assignments of the output
+	for _, tt := range []struct {
+		shell string
+		style QuoteStyle
+	}{
+		{shell: "sh", style: QuotePOSIX},
+		{shell: "bash", style: QuoteBash},
+	} {
+		path, err := exec.LookPath(tt.shell)
+		if err != nil {
+			t.Log(tt.shell, "not found")
+			continue
+		}
+		for _, value := range []string{"a b", "", "it's", "$HOME `id`", "tab\there", "a=b;c"} {
+			c := &CommandLine{Env: []string{"FOO=" + value}, ExecutePath: "printenv", Args: []string{"FOO"}}
+			out, err := exec.Command(path, "-c", c.String(tt.style)).Output()
+			if assert.NoError(t, err, c.String(tt.style)) {
+				assert.Equal(t, value+"\n", string(out), c.String(tt.style))
+			}
+		}
+	}
+}
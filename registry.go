@@ -0,0 +1,98 @@
+package cmdline
+
+import (
+	"strings"
+	"sync/atomic"
+)
+
+// ExtractorFunc fills in what an executable runs, like the Python or the Sub
+// field, from cmdline.Args. The returned error leaves the result partial, it
+// is recorded in the Diagnostics of cmdline.
+type ExtractorFunc func(cmdline *CommandLine) error
+
+// Registry maps executable names to the extractors that know their
+// arguments. A Registry must not be changed while a Parser uses it.
+type Registry struct {
+	extractors        map[string]ExtractorFunc
+	windowsExtractors map[string]ExtractorFunc
+	// matchers come first, they hold the rules of the rule files
+	matchers []registryMatcher
+	// id tells the registries and their changes apart in the keys of the
+	// cache, it is a new number after every change
+	id uint64
+}
+
+// registryIDs numbers the registries and their changes
+var registryIDs atomic.Uint64
+
+type registryMatcher struct {
+	match func(isWindows bool, exe string) bool
+	fn    ExtractorFunc
+}
+
+// defaultRegistry holds the built-in extractors, it is never changed
+var defaultRegistry = NewRegistry()
+
+// NewRegistry returns a registry with the built-in extractors, like the ones
+// for java, python and svchost.
+func NewRegistry() *Registry {
+	r := &Registry{
+		extractors:        map[string]ExtractorFunc{},
+		windowsExtractors: map[string]ExtractorFunc{},
+		id:                registryIDs.Add(1),
+	}
+	for name, fn := range binsWithContext {
+		r.extractors[name] = fn
+	}
+	for name, fn := range windowsBinsWithContext {
+		r.windowsExtractors[name] = fn
+	}
+	return r
+}
+
+// Register sets the extractor of the executable name, the name is without
+// path and ".exe", e.g. "python3". A name without version also matches the
+// versioned executables, "python" matches "python3.11".
+func (r *Registry) Register(name string, fn ExtractorFunc) {
+	r.extractors[name] = fn
+	r.id = registryIDs.Add(1)
+}
+
+// RegisterWindows sets the extractor of a windows executable, it is tried
+// before the ones of Register and the name is case insensitive.
+func (r *Registry) RegisterWindows(name string, fn ExtractorFunc) {
+	r.windowsExtractors[strings.ToLower(name)] = fn
+	r.id = registryIDs.Add(1)
+}
+
+// registerMatcher adds an extractor for the executables match accepts, it
+// is tried before the names of Register
+func (r *Registry) registerMatcher(match func(isWindows bool, exe string) bool, fn ExtractorFunc) {
+	r.matchers = append(r.matchers, registryMatcher{match: match, fn: fn})
+	r.id = registryIDs.Add(1)
+}
+
+// lookup finds the extractor of exe, the name without path and ".exe"
+func (r *Registry) lookup(isWindows bool, exe string) ExtractorFunc {
+	for _, m := range r.matchers {
+		if m.match(isWindows, exe) {
+			return m.fn
+		}
+	}
+
+	if isWindows {
+		if fn, ok := r.windowsExtractors[strings.ToLower(exe)]; ok {
+			return fn
+		}
+	}
+
+	if fn, ok := r.extractors[exe]; ok {
+		return fn
+	}
+
+	baseExe, _ := splitVersion(exe)
+	if fn, ok := r.extractors[baseExe]; ok {
+		return fn
+	}
+	return nil
+}
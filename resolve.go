@@ -0,0 +1,129 @@
+package cmdline
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// ReadLinkFS is a file system with symbolic links, its methods are the ones
+// of fs.ReadLinkFS in go 1.25. The names are slash separated and without the
+// leading "/", like "usr/bin/python" for /usr/bin/python.
+type ReadLinkFS interface {
+	fs.FS
+	// ReadLink returns the target of the symbolic link name
+	ReadLink(name string) (string, error)
+	// Lstat returns the FileInfo of name without following a link
+	Lstat(name string) (fs.FileInfo, error)
+}
+
+// maxLinks is how many symbolic links are followed for a path, like
+// MAXSYMLINKS of linux
+const maxLinks = 40
+
+// Resolution is the executable with its symbolic links followed, like
+// /usr/bin/python3.9 for /usr/bin/python through /etc/alternatives/python
+type Resolution struct {
+	// Path is the executable the links lead to
+	Path string
+	// Links are the paths after each link followed, the last one is Path
+	Links []string
+}
+
+// DirFS returns the file system of the directory dir, with its links. The
+// absolute links are taken as relative to dir, so DirFS("/proc/1234/root")
+// resolves the executables of a process in another mount namespace.
+func DirFS(dir string) ReadLinkFS {
+	return dirFS(dir)
+}
+
+type dirFS string
+
+func (dir dirFS) Open(name string) (fs.File, error) {
+	return os.DirFS(string(dir)).Open(name)
+}
+
+func (dir dirFS) ReadLink(name string) (string, error) {
+	if !fs.ValidPath(name) {
+		return "", &fs.PathError{Op: "readlink", Path: name, Err: fs.ErrInvalid}
+	}
+	return os.Readlink(filepath.Join(string(dir), filepath.FromSlash(name)))
+}
+
+func (dir dirFS) Lstat(name string) (fs.FileInfo, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "lstat", Path: name, Err: fs.ErrInvalid}
+	}
+	return os.Lstat(filepath.Join(string(dir), filepath.FromSlash(name)))
+}
+
+// resolveExecutable follows the links of the executable exe in the file
+// system of the parser, nil when it isn't a ReadLinkFS, exe is no link or
+// exe can't be found. The executables without a directory would need a
+// lookup in PATH and aren't resolved.
+func (p *Parser) resolveExecutable(exe string) (*Resolution, error) {
+	fsys, ok := p.fsys.(ReadLinkFS)
+	if !ok || p.isWindows() || !strings.Contains(exe, "/") {
+		return nil, nil
+	}
+	if !path.IsAbs(exe) {
+		if !path.IsAbs(p.workingDir) {
+			return nil, nil
+		}
+		exe = path.Join(p.workingDir, exe)
+	}
+
+	resolved, links, err := evalLinks(fsys, exe)
+	if err != nil || len(links) == 0 {
+		return nil, err
+	}
+	return &Resolution{Path: resolved, Links: links}, nil
+}
+
+// evalLinks follows the links of every directory of the absolute path name
+// and of name itself, like filepath.EvalSymlinks, and returns the path after
+// each link followed
+func evalLinks(fsys ReadLinkFS, name string) (string, []string, error) {
+	var links []string
+	resolved, rest := "/", name
+	for {
+		rest = strings.TrimLeft(rest, "/")
+		if rest == "" {
+			return resolved, links, nil
+		}
+		var elem string
+		elem, rest, _ = strings.Cut(rest, "/")
+		switch elem {
+		case ".":
+			continue
+		case "..":
+			resolved = path.Dir(resolved)
+			continue
+		}
+
+		next := path.Join(resolved, elem)
+		info, err := fsys.Lstat(next[1:])
+		if err != nil {
+			return "", nil, err
+		}
+		if info.Mode()&fs.ModeSymlink == 0 {
+			resolved = next
+			continue
+		}
+		if len(links) == maxLinks {
+			return "", nil, fmt.Errorf("%s: %w", name, ErrLinkLoop)
+		}
+		target, err := fsys.ReadLink(next[1:])
+		if err != nil {
+			return "", nil, err
+		}
+		if path.IsAbs(target) {
+			resolved = "/"
+		}
+		rest = target + "/" + rest
+		links = append(links, path.Join(resolved, rest))
+	}
+}
@@ -0,0 +1,173 @@
+package cmdline
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// linkFS is a MapFS whose files with fs.ModeSymlink are links to their Data
+type linkFS struct {
+	fstest.MapFS
+}
+
+// Open follows the links like an operating system, a loop is an error
+func (fsys linkFS) Open(name string) (fs.File, error) {
+	resolved, _, err := evalLinks(fsys, "/"+name)
+	if err != nil {
+		return nil, err
+	}
+	return fsys.MapFS.Open(strings.TrimPrefix(resolved, "/"))
+}
+
+func (fsys linkFS) ReadLink(name string) (string, error) {
+	f, ok := fsys.MapFS[name]
+	if !ok || f.Mode&fs.ModeSymlink == 0 {
+		return "", &fs.PathError{Op: "readlink", Path: name, Err: fs.ErrInvalid}
+	}
+	return string(f.Data), nil
+}
+
+func (fsys linkFS) Lstat(name string) (fs.FileInfo, error) {
+	if f, ok := fsys.MapFS[name]; ok && f.Mode&fs.ModeSymlink != 0 {
+		return linkInfo(name), nil
+	}
+	return fsys.MapFS.Stat(name)
+}
+
+type linkInfo string
+
+func (info linkInfo) Name() string       { return filepath.Base(string(info)) }
+func (info linkInfo) Size() int64        { return 0 }
+func (info linkInfo) Mode() fs.FileMode  { return fs.ModeSymlink | 0o777 }
+func (info linkInfo) ModTime() time.Time { return time.Time{} }
+func (info linkInfo) IsDir() bool        { return false }
+func (info linkInfo) Sys() any           { return nil }
+
+func link(target string) *fstest.MapFile {
+	return &fstest.MapFile{Mode: fs.ModeSymlink, Data: []byte(target)}
+}
+
+func TestResolve(t *testing.T) {
+	fsys := linkFS{fstest.MapFS{
+		"bin":                     link("usr/bin"),
+		"usr/bin/python":          link("/etc/alternatives/python"),
+		"etc/alternatives/python": link("/usr/bin/python3.9"),
+		"usr/bin/python3.9":       &fstest.MapFile{},
+		"usr/local/bin/py":        link("../../bin/python3.9"),
+		"usr/bin/java":            link("/etc/alternatives/java"),
+		"etc/alternatives/java":   link("/usr/lib/jvm/java-17-openjdk-amd64/bin/java"),
+		"usr/lib/jvm/java-17-openjdk-amd64/bin/java": &fstest.MapFile{},
+		"usr/bin/sudo":            link("sudo-rs"),
+		"usr/bin/sudo-rs":         &fstest.MapFile{},
+		"usr/bin/loop":            link("loop2"),
+		"usr/bin/loop2":           link("./loop"),
+		"srv/app/venv/bin/python": link("/usr/bin/python"),
+	}}
+	p := NewParser(WithFS(fsys), WithWorkingDir("/srv/app"))
+
+	tests := []struct {
+		cmdline  string
+		resolved *Resolution
+		runtime  *Runtime
+	}{
+		{
+			cmdline: "/usr/bin/python app.py",
+			resolved: &Resolution{Path: "/usr/bin/python3.9", Links: []string{
+				"/etc/alternatives/python",
+				"/usr/bin/python3.9",
+			}},
+			runtime: &Runtime{Name: "python", Version: "3.9"},
+		},
+		{
+			cmdline: "/bin/java -jar app.jar",
+			resolved: &Resolution{Path: "/usr/lib/jvm/java-17-openjdk-amd64/bin/java", Links: []string{
+				"/usr/bin/java",
+				"/etc/alternatives/java",
+				"/usr/lib/jvm/java-17-openjdk-amd64/bin/java",
+			}},
+			runtime: &Runtime{Name: "java", Version: "17"},
+		},
+		{
+			// py is no known name, python3.9 is
+			cmdline:  "/usr/local/bin/py app.py",
+			resolved: &Resolution{Path: "/usr/bin/python3.9", Links: []string{"/usr/bin/python3.9"}},
+			runtime:  &Runtime{Name: "python", Version: "3.9"},
+		},
+		{
+			cmdline: "./venv/bin/python app.py",
+			resolved: &Resolution{Path: "/usr/bin/python3.9", Links: []string{
+				"/usr/bin/python",
+				"/etc/alternatives/python",
+				"/usr/bin/python3.9",
+			}},
+			runtime: &Runtime{Name: "python", Version: "3.9"},
+		},
+		{cmdline: "/usr/bin/python3.9 app.py", runtime: &Runtime{Name: "python", Version: "3.9"}},
+		{cmdline: "/opt/python/bin/python app.py", runtime: &Runtime{Name: "python"}},
+		{cmdline: "python app.py", runtime: &Runtime{Name: "python"}},
+	}
+	for _, tt := range tests {
+		c, err := p.ParseCommandLine(tt.cmdline)
+		if assert.NoError(t, err, tt.cmdline) {
+			assert.Equal(t, tt.resolved, c.Resolved, tt.cmdline)
+			assert.Equal(t, tt.runtime, c.Runtime, tt.cmdline)
+			assert.True(t, c.Python != nil || c.Java != nil, tt.cmdline)
+		}
+	}
+
+	// sudo-rs is unknown, it runs as the sudo it is invoked as
+	c, err := p.ParseCommandLine("/usr/bin/sudo python app.py")
+	assert.NoError(t, err)
+	assert.Equal(t, &Resolution{Path: "/usr/bin/sudo-rs", Links: []string{"/usr/bin/sudo-rs"}}, c.Resolved)
+	if assert.NotNil(t, c.Sub) {
+		assert.Equal(t, "python", c.Sub.Command)
+	}
+	assert.True(t, MustCompileMatcher(`exe.resolved == "/usr/bin/sudo-rs" && exe.base == "sudo"`).Match(c))
+
+	c, err = p.ParseCommandLine("/usr/bin/loop -x")
+	assert.NoError(t, err)
+	assert.Nil(t, c.Resolved)
+	if assert.Len(t, c.Diagnostics, 1) {
+		assert.True(t, errors.Is(c.Diagnostics[0].Err, ErrLinkLoop))
+		assert.Equal(t, -1, c.Diagnostics[0].Index)
+	}
+	_, err = NewParser(WithFS(fsys), WithStrictness(StrictnessStrict)).ParseCommandLine("/usr/bin/loop -x")
+	assert.True(t, errors.Is(err, ErrLinkLoop))
+
+	// the wrapped commands are resolved too
+	c, err = NewParser(WithFS(fsys), WithMaxDepth(1)).ParseCommandLine("sudo /usr/bin/python app.py")
+	assert.NoError(t, err)
+	assert.Nil(t, c.Resolved)
+	assert.Equal(t, &Runtime{Name: "python", Version: "3.9"}, c.Sub.CommandLine.Runtime)
+
+	// a plain fs.FS has no links
+	c, _ = NewParser(WithFS(struct{ fs.FS }{fsys})).ParseCommandLine("/usr/bin/python app.py")
+	assert.Nil(t, c.Resolved)
+	assert.Equal(t, &Runtime{Name: "python"}, c.Runtime)
+}
+
+func TestDirFS(t *testing.T) {
+	root := t.TempDir()
+	assert.NoError(t, os.MkdirAll(filepath.Join(root, "usr", "bin"), 0o755))
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "usr", "bin", "ruby3.2"), nil, 0o755))
+	// the absolute links stay in root
+	if err := os.Symlink("/usr/bin/ruby3.2", filepath.Join(root, "usr", "bin", "ruby")); err != nil {
+		t.Skip("no symbolic links:", err)
+	}
+
+	c, err := NewParser(WithFS(DirFS(root))).ParseCommandLine("/usr/bin/ruby app.rb")
+	assert.NoError(t, err)
+	assert.Equal(t, &Resolution{Path: "/usr/bin/ruby3.2", Links: []string{"/usr/bin/ruby3.2"}}, c.Resolved)
+	assert.Equal(t, &Runtime{Name: "ruby", Version: "3.2"}, c.Runtime)
+
+	_, err = DirFS(root).ReadLink("../etc/passwd")
+	assert.True(t, errors.Is(err, fs.ErrInvalid))
+}
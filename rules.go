@@ -0,0 +1,434 @@
+package cmdline
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// RuleFileVersion is the version of the rule file format
+const RuleFileVersion = 1
+
+// The output fields a positional of a rule can be mapped to, the arguments
+// after the positional become the Args of the field
+const (
+	RuleFieldSubCommand   = "sub.command"
+	RuleFieldPythonScript = "python.script"
+	RuleFieldRubyScript   = "ruby.script"
+	RuleFieldJavaMain     = "java.main"
+)
+
+// Roles with a meaning of their own: the file kinds like "config" and "log"
+// are reported by Files, "port" and "address" by Endpoints
+const (
+	RolePort    = "port"
+	RoleAddress = "address"
+)
+
+// Rule describes the arguments of an executable without Go code, the rules
+// are usually read from a rule file with Registry.LoadRules:
+//
+//	version: 1
+//	rules:
+//	  - name: acme-agent
+//	    match:
+//	      names: [acme-agent]
+//	      globs: ["acme-*-agent"]
+//	      version_suffix: true
+//	    style: gnu
+//	    options:
+//	      - names: [--conf, -c]
+//	        value: true
+//	        role: config
+//	      - names: [-v, --verbose]
+//	    positionals:
+//	      - role: command
+//	    fields:
+//	      sub.command: command
+type Rule struct {
+	Name  string    `yaml:"name"`
+	Match RuleMatch `yaml:"match"`
+	// Style is how the options are written, gnu (the default), posix, java
+	// or windows, see OptionStyle
+	Style       string           `yaml:"style"`
+	Options     []RuleOption     `yaml:"options"`
+	Positionals []RulePositional `yaml:"positionals"`
+	// Fields maps an output field, like "sub.command", to the role of the
+	// positional that fills it
+	Fields map[string]string `yaml:"fields"`
+}
+
+// RuleMatch selects the executables of a rule by their name without path
+// and ".exe"
+type RuleMatch struct {
+	Names []string `yaml:"names"`
+	// Globs are patterns of path.Match, e.g. "acme-*"
+	Globs []string `yaml:"globs"`
+	// VersionSuffix lets the names match the versioned executables too,
+	// "acme-agent" matches "acme-agent2.1"
+	VersionSuffix bool `yaml:"version_suffix"`
+}
+
+// RuleOption is an option of the executable, it takes its value as the next
+// argument or after '=' when Value is set, else it is a boolean flag
+type RuleOption struct {
+	Names []string `yaml:"names"`
+	Value bool     `yaml:"value"`
+	// Role names the value in CustomArgs, empty for the values that don't
+	// matter
+	Role string `yaml:"role"`
+}
+
+// RulePositional is an argument that isn't an option, in the order they
+// are found
+type RulePositional struct {
+	Role string `yaml:"role"`
+}
+
+// CustomArgs is what a rule found in the arguments
+type CustomArgs struct {
+	// Rule is the name of the rule
+	Rule   string
+	Values []RoleValue
+}
+
+// RoleValue is the value of an option or positional with a role
+type RoleValue struct {
+	Role  string
+	Value string
+	// Index is the index in Args of the argument with the value
+	Index int
+}
+
+// Value returns the first value of role, or "" if there is none
+func (c *CustomArgs) Value(role string) string {
+	for _, v := range c.Values {
+		if v.Role == role {
+			return v.Value
+		}
+	}
+	return ""
+}
+
+type ruleFile struct {
+	Version int    `yaml:"version"`
+	Rules   []Rule `yaml:"rules"`
+}
+
+// LoadRules reads a rule file, YAML or JSON, and registers its rules. The
+// file is validated first, nothing is registered when it is invalid. The
+// rules are tried before the extractors of the names, in the order they are
+// loaded.
+func (r *Registry) LoadRules(data []byte) error {
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+
+	var file ruleFile
+	if err := decoder.Decode(&file); err != nil {
+		return errors.New("invalid rule file - " + err.Error())
+	}
+	if file.Version != RuleFileVersion {
+		return fmt.Errorf("invalid rule file - version %d, expected %d", file.Version, RuleFileVersion)
+	}
+
+	var errs []error
+	names := map[string]bool{}
+	for idx := range file.Rules {
+		rule := &file.Rules[idx]
+		if names[rule.Name] {
+			errs = append(errs, fmt.Errorf("rule %q: defined twice", rule.Name))
+		}
+		names[rule.Name] = true
+		if err := rule.Validate(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	for idx := range file.Rules {
+		r.mustRegisterRule(file.Rules[idx])
+	}
+	return nil
+}
+
+// LoadRuleFile is LoadRules with the content of the file name
+func (r *Registry) LoadRuleFile(name string) error {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return err
+	}
+	if err := r.LoadRules(data); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	return nil
+}
+
+// RegisterRule validates rule and registers it like LoadRules does
+func (r *Registry) RegisterRule(rule Rule) error {
+	if err := rule.Validate(); err != nil {
+		return err
+	}
+	r.mustRegisterRule(rule)
+	return nil
+}
+
+func (r *Registry) mustRegisterRule(rule Rule) {
+	// the rule is copied, the caller may change its slices and map
+	rule = rule.clone()
+	grammar := rule.grammar()
+	r.registerMatcher(rule.Match.match, func(cmdline *CommandLine) error {
+		return rule.extract(grammar, cmdline)
+	})
+}
+
+// clone is a deep copy of the rule
+func (rule Rule) clone() Rule {
+	rule.Match.Names = append([]string(nil), rule.Match.Names...)
+	rule.Match.Globs = append([]string(nil), rule.Match.Globs...)
+	rule.Options = append([]RuleOption(nil), rule.Options...)
+	for i := range rule.Options {
+		rule.Options[i].Names = append([]string(nil), rule.Options[i].Names...)
+	}
+	rule.Positionals = append([]RulePositional(nil), rule.Positionals...)
+	if rule.Fields != nil {
+		fields := make(map[string]string, len(rule.Fields))
+		for field, role := range rule.Fields {
+			fields[field] = role
+		}
+		rule.Fields = fields
+	}
+	return rule
+}
+
+// Validate reports all the problems of the rule at once
+func (rule *Rule) Validate() error {
+	var errs []error
+	fail := func(format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf("rule %q: "+format, append([]interface{}{rule.Name}, args...)...))
+	}
+
+	if rule.Name == "" {
+		fail("name is missing")
+	}
+	if len(rule.Match.Names) == 0 && len(rule.Match.Globs) == 0 {
+		fail("match has neither names nor globs")
+	}
+	for _, name := range rule.Match.Names {
+		if name == "" || strings.ContainsAny(name, `/\`) {
+			fail("match name %q must be an executable name without path", name)
+		}
+	}
+	for _, glob := range rule.Match.Globs {
+		if _, err := path.Match(glob, ""); err != nil || glob == "" {
+			fail("match glob %q is no valid pattern", glob)
+		}
+	}
+
+	style, ok := ruleStyles[rule.Style]
+	if !ok {
+		fail("unknown style %q", rule.Style)
+	}
+
+	flags := map[string]bool{}
+	for _, option := range rule.Options {
+		if len(option.Names) == 0 {
+			fail("option without names")
+		}
+		for _, name := range option.Names {
+			if len(name) < 2 || !(name[0] == '-' || name[0] == '/' && style == StyleWindows) || strings.ContainsRune(name, '=') {
+				fail("option %q must start with '-' and have no '='", name)
+			}
+			if flags[name] {
+				fail("option %q is defined twice", name)
+			}
+			flags[name] = true
+		}
+		if option.Role != "" {
+			if !option.Value {
+				fail("option %q has a role but takes no value", strings.Join(option.Names, ","))
+			}
+			if !isRoleName(option.Role) {
+				fail("role %q must be lower case letters, digits and '_'", option.Role)
+			}
+		}
+	}
+
+	positionals := map[string]int{}
+	for idx, p := range rule.Positionals {
+		if !isRoleName(p.Role) {
+			fail("role %q of positional %d must be lower case letters, digits and '_'", p.Role, idx)
+		}
+		if _, ok := positionals[p.Role]; ok {
+			fail("role %q of positional %d is defined twice", p.Role, idx)
+		}
+		positionals[p.Role] = idx
+	}
+
+	if len(rule.Fields) > 1 {
+		fail("fields can map only one of %s, %s, %s and %s",
+			RuleFieldSubCommand, RuleFieldPythonScript, RuleFieldRubyScript, RuleFieldJavaMain)
+	}
+	fields := make([]string, 0, len(rule.Fields))
+	for field := range rule.Fields {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	for _, field := range fields {
+		role := rule.Fields[field]
+		switch field {
+		case RuleFieldSubCommand, RuleFieldPythonScript, RuleFieldRubyScript, RuleFieldJavaMain:
+		default:
+			fail("unknown field %q", field)
+			continue
+		}
+		if _, ok := positionals[role]; !ok {
+			fail("field %q needs the role %q of a positional", field, role)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func isRoleName(s string) bool {
+	if s == "" || s[0] < 'a' || s[0] > 'z' {
+		return false
+	}
+	for _, c := range []byte(s) {
+		if !(c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '_') {
+			return false
+		}
+	}
+	return true
+}
+
+func (m *RuleMatch) match(isWindows bool, exe string) bool {
+	if isWindows {
+		exe = strings.ToLower(exe)
+	}
+	baseExe := ""
+	if m.VersionSuffix {
+		baseExe, _ = splitVersion(exe)
+	}
+	for _, name := range m.Names {
+		if isWindows {
+			name = strings.ToLower(name)
+		}
+		if exe == name || baseExe == name {
+			return true
+		}
+	}
+	for _, glob := range m.Globs {
+		if isWindows {
+			glob = strings.ToLower(glob)
+		}
+		if ok, _ := path.Match(glob, exe); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// ruleStyles are the values of the style of a rule
+var ruleStyles = map[string]OptionStyle{
+	"":        StyleGNU,
+	"gnu":     StyleGNU,
+	"posix":   StylePOSIX,
+	"java":    StyleJava,
+	"windows": StyleWindows,
+}
+
+// grammar is the option grammar of the rule, the options of the grammar are
+// in the order of rule.Options
+func (rule *Rule) grammar() *OptionGrammar {
+	g := &OptionGrammar{Style: ruleStyles[rule.Style], Interspersed: true}
+	for _, option := range rule.Options {
+		spec := OptionSpec{Names: option.Names}
+		if option.Value {
+			spec.Value = RequiredValue
+		}
+		g.Options = append(g.Options, spec)
+	}
+
+	// the arguments after the target are not the ones of the rule
+	for _, role := range rule.Fields {
+		for idx, p := range rule.Positionals {
+			if p.Role == role {
+				g.MaxPositionals = idx + 1
+			}
+		}
+	}
+	return g
+}
+
+func (rule *Rule) extract(grammar *OptionGrammar, cmdline *CommandLine) error {
+	custom := &CustomArgs{Rule: rule.Name}
+	cmdline.Custom = custom
+	parsed := grammar.ParseOptions(cmdline.Args)
+
+	for _, o := range parsed.Options {
+		if o.Spec == nil {
+			exe := executableName(cmdline.isWindows(), cmdline.ExecutePath)
+			cmdline.Diagnostics = append(cmdline.Diagnostics, Diagnostic{
+				Index: o.Index,
+				Err:   &UnknownOptionError{Executable: exe, Option: o.Name, Index: o.Index},
+			})
+			continue
+		}
+		for idx := range grammar.Options {
+			if role := rule.Options[idx].Role; &grammar.Options[idx] == o.Spec && role != "" && o.HasValue {
+				custom.Values = append(custom.Values, RoleValue{Role: role, Value: o.Value, Index: o.ValueIndex})
+			}
+		}
+	}
+
+	var targetField, targetRole string
+	for field, role := range rule.Fields {
+		targetField, targetRole = field, role
+	}
+	for idx, argIndex := range parsed.Positionals {
+		if idx >= len(rule.Positionals) {
+			break
+		}
+		role := rule.Positionals[idx].Role
+		custom.Values = append(custom.Values, RoleValue{Role: role, Value: cmdline.Args[argIndex], Index: argIndex})
+		if role == targetRole {
+			cmdline.traceArg(argIndex, TokenTarget, "")
+			setRuleField(cmdline, targetField, cmdline.Args[argIndex], cmdline.Args[argIndex+1:])
+			targetField = ""
+		}
+	}
+	sort.SliceStable(custom.Values, func(i, j int) bool {
+		return custom.Values[i].Index < custom.Values[j].Index
+	})
+
+	switch targetField {
+	case RuleFieldSubCommand:
+		return ErrNoCommand
+	case RuleFieldPythonScript, RuleFieldRubyScript:
+		return ErrNoScript
+	case RuleFieldJavaMain:
+		return ErrNoMainClass
+	}
+	return nil
+}
+
+func setRuleField(cmdline *CommandLine, field, value string, rest []string) {
+	switch field {
+	case RuleFieldSubCommand:
+		cmdline.Sub = &SubCommand{Command: value, Args: rest}
+	case RuleFieldPythonScript:
+		cmdline.Python = &PythonArgs{FilePath: value, Args: rest}
+	case RuleFieldRubyScript:
+		cmdline.Ruby = &RubyArgs{FilePath: value, Args: rest}
+	case RuleFieldJavaMain:
+		cmdline.Java = &JavaArgs{ClassName: value, Args: rest}
+	}
+}
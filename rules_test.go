@@ -0,0 +1,262 @@
+package cmdline
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadRules(t *testing.T) {
+	registry := NewRegistry()
+	if err := registry.LoadRuleFile("testdata/rules/acme.yaml"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		isWindows   bool
+		cmdline     string
+		expected    *CustomArgs
+		python      *PythonArgs
+		diagnostics []Diagnostic
+	}{
+		{
+			cmdline: "acme-agent --conf x.yml run",
+			expected: &CustomArgs{Rule: "acme-agent", Values: []RoleValue{
+				{Role: "config", Value: "x.yml", Index: 1},
+				{Role: "command", Value: "run", Index: 2},
+			}},
+		},
+		{
+			cmdline: "/opt/acme/bin/acme-agent2.1 -v --log-level=debug -c=/etc/acme.yml --listen :7070 -- --run",
+			expected: &CustomArgs{Rule: "acme-agent", Values: []RoleValue{
+				{Role: "config", Value: "/etc/acme.yml", Index: 2},
+				{Role: "address", Value: ":7070", Index: 4},
+				{Role: "command", Value: "--run", Index: 6},
+			}},
+		},
+		{
+			cmdline: "acme-edge-agent --debug run",
+			expected: &CustomArgs{Rule: "acme-agent", Values: []RoleValue{
+				{Role: "command", Value: "run", Index: 1},
+			}},
+			diagnostics: []Diagnostic{
+				{Index: 0, Err: &UnknownOptionError{Executable: "acme-edge-agent", Option: "--debug", Index: 0}},
+			},
+		},
+		{
+			isWindows: true,
+			cmdline:   `C:\acme\ACME-AGENT.EXE --conf acme.yml`,
+			expected: &CustomArgs{Rule: "acme-agent", Values: []RoleValue{
+				{Role: "config", Value: "acme.yml", Index: 1},
+			}},
+		},
+		{
+			cmdline: "acme-runner --pid-file /run/acme.pid nightly jobs/cleanup.py --dry-run",
+			expected: &CustomArgs{Rule: "acme-runner", Values: []RoleValue{
+				{Role: "pid", Value: "/run/acme.pid", Index: 1},
+				{Role: "profile", Value: "nightly", Index: 2},
+				{Role: "script", Value: "jobs/cleanup.py", Index: 3},
+			}},
+			python: &PythonArgs{FilePath: "jobs/cleanup.py", Args: []string{"--dry-run"}},
+		},
+		{
+			cmdline: "acme-runner nightly",
+			expected: &CustomArgs{Rule: "acme-runner", Values: []RoleValue{
+				{Role: "profile", Value: "nightly", Index: 0},
+			}},
+			diagnostics: []Diagnostic{{Index: -1, Err: ErrNoScript}},
+		},
+		{
+			isWindows: true,
+			cmdline:   `C:\acme\AcmeSvc.exe /RUN -Config:C:\acme\svc.yml`,
+			expected: &CustomArgs{Rule: "acme-service", Values: []RoleValue{
+				{Role: "config", Value: `C:\acme\svc.yml`, Index: 1},
+			}},
+		},
+		{
+			// without version_suffix
+			cmdline: "acme-runner2 nightly jobs/cleanup.py",
+		},
+		{
+			cmdline: "acme-agentx run",
+		},
+	}
+
+	for _, tt := range tests {
+		flavor := FlavorPOSIX
+		if tt.isWindows {
+			flavor = FlavorWindows
+		}
+		c, _ := NewParser(WithFlavor(flavor), WithRegistry(registry)).ParseCommandLine(tt.cmdline)
+		if !assert.NotNil(t, c, tt.cmdline) {
+			continue
+		}
+		assert.Equal(t, tt.expected, c.Custom, tt.cmdline)
+		assert.Equal(t, tt.python, c.Python, tt.cmdline)
+		assert.Equal(t, tt.diagnostics, c.Diagnostics, tt.cmdline)
+	}
+
+	// the default registry doesn't know the rules
+	c, err := ParseCommandLine(false, "acme-agent --conf x.yml run")
+	assert.NoError(t, err)
+	assert.Nil(t, c.Custom)
+}
+
+func TestLoadRulesJSON(t *testing.T) {
+	registry := NewRegistry()
+	if err := registry.LoadRuleFile("testdata/rules/acme.json"); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := NewParser(WithRegistry(registry)).ParseCommandLine("acme-agent3 -v --conf x.yml run")
+	assert.NoError(t, err)
+	assert.Equal(t, &CustomArgs{Rule: "acme-agent", Values: []RoleValue{
+		{Role: "config", Value: "x.yml", Index: 2},
+		{Role: "command", Value: "run", Index: 3},
+	}}, c.Custom)
+}
+
+func TestRuleRoles(t *testing.T) {
+	registry := NewRegistry()
+	if err := registry.LoadRuleFile("testdata/rules/acme.yaml"); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := NewParser(WithRegistry(registry), WithWorkingDir("/opt/acme")).
+		ParseCommandLine("acme-agent -c etc/acme.yml --listen 127.0.0.1:7070 run")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []FileRef{
+		{Kind: FileConfig, Path: "etc/acme.yml", Resolved: "/opt/acme/etc/acme.yml", Index: 1},
+	}, c.Files())
+	assert.Equal(t, []Endpoint{
+		{Host: "127.0.0.1", Port: 7070, Protocol: "tcp", Index: 3},
+	}, c.Endpoints())
+	assert.True(t, MustCompileMatcher(`custom.rule == "acme-agent" && custom["command"] == "run"`).Match(c))
+
+	redacted := c.Redact(RedactionRules{Binaries: map[string]BinaryRedaction{"acme-agent": {Flags: []string{"--listen"}}}})
+	assert.Equal(t, defaultRedactedValue, redacted.Custom.Value(RoleAddress))
+	assert.Equal(t, "etc/acme.yml", redacted.Custom.Value(FileConfig))
+}
+
+func TestLoadRulesErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		expected []string
+	}{
+		{
+			name:     "version",
+			data:     "rules: []",
+			expected: []string{"invalid rule file - version 0, expected 1"},
+		},
+		{
+			name:     "unknown field",
+			data:     "version: 1\nrules:\n  - name: a\n    matches: {names: [a]}\n",
+			expected: []string{"invalid rule file - yaml: unmarshal errors:\n  line 4: field matches not found in type cmdline.Rule"},
+		},
+		{
+			name: "invalid rules",
+			data: `version: 1
+rules:
+  - match: {}
+  - name: b
+    match: {names: [bin/b], globs: ["b["]}
+    options:
+      - names: [x, --y=]
+      - names: [-v]
+        role: verbose
+      - names: [-v]
+        value: true
+        role: Level
+    positionals:
+      - role: script
+      - role: script
+    fields:
+      go.main: script
+  - name: c
+    match: {names: [c]}
+    fields:
+      sub.command: command
+  - name: c
+    match: {names: [c]}
+    style: dos
+`,
+			expected: []string{
+				`rule "": name is missing`,
+				`rule "": match has neither names nor globs`,
+				`rule "b": match name "bin/b" must be an executable name without path`,
+				`rule "b": match glob "b[" is no valid pattern`,
+				`rule "b": option "x" must start with '-' and have no '='`,
+				`rule "b": option "--y=" must start with '-' and have no '='`,
+				`rule "b": option "-v" has a role but takes no value`,
+				`rule "b": option "-v" is defined twice`,
+				`rule "b": role "Level" must be lower case letters, digits and '_'`,
+				`rule "b": role "script" of positional 1 is defined twice`,
+				`rule "b": unknown field "go.main"`,
+				`rule "c": field "sub.command" needs the role "command" of a positional`,
+				`rule "c": defined twice`,
+				`rule "c": unknown style "dos"`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		registry := NewRegistry()
+		err := registry.LoadRules([]byte(tt.data))
+		if !assert.Error(t, err, tt.name) {
+			continue
+		}
+
+		var messages []string
+		if joined, ok := err.(interface{ Unwrap() []error }); ok {
+			for _, e := range joined.Unwrap() {
+				if inner, ok := e.(interface{ Unwrap() []error }); ok {
+					for _, e := range inner.Unwrap() {
+						messages = append(messages, e.Error())
+					}
+					continue
+				}
+				messages = append(messages, e.Error())
+			}
+		} else {
+			messages = []string{err.Error()}
+		}
+		assert.Equal(t, tt.expected, messages, tt.name)
+		assert.Empty(t, registry.matchers, tt.name)
+	}
+
+	err := NewRegistry().RegisterRule(Rule{Name: "a", Match: RuleMatch{Globs: []string{"a*"}}})
+	assert.NoError(t, err)
+	err = NewRegistry().RegisterRule(Rule{Name: "a"})
+	assert.EqualError(t, err, `rule "a": match has neither names nor globs`)
+}
+
+func TestRegisterRuleCopies(t *testing.T) {
+	rule := Rule{
+		Name:        "acme-runner",
+		Match:       RuleMatch{Names: []string{"acme-runner"}},
+		Options:     []RuleOption{{Names: []string{"-c"}, Value: true, Role: FileConfig}},
+		Positionals: []RulePositional{{Role: "command"}},
+		Fields:      map[string]string{RuleFieldSubCommand: "command"},
+	}
+	registry := NewRegistry()
+	if err := registry.RegisterRule(rule); err != nil {
+		t.Fatal(err)
+	}
+
+	// the changes after the registration don't reach the registered rule
+	rule.Match.Names[0] = "other"
+	rule.Options[0].Names[0] = "-x"
+	rule.Fields[RuleFieldSubCommand] = "other"
+
+	c, err := NewParser(WithRegistry(registry)).ParseCommandLine("acme-runner -c /etc/acme.yml python app.py")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "/etc/acme.yml", c.Custom.Value(FileConfig))
+	if assert.NotNil(t, c.Sub) {
+		assert.Equal(t, "python", c.Sub.Command)
+	}
+}
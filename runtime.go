@@ -0,0 +1,207 @@
+package cmdline
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Runtime is the interpreter or virtual machine that runs the program
+type Runtime struct {
+	// Name is "python", "ruby", "java", "node", "php" or "perl"
+	Name string
+	// Version is the dotted version found in the executable name, like
+	// python3.11, or in the directories of the executable, like
+	// /usr/lib/jvm/java-17-openjdk/bin/java, empty if there is none
+	Version string
+	// Manager is the version manager the executable comes from: "pyenv",
+	// "rbenv", "asdf", "sdkman" or "nvm"
+	Manager string
+}
+
+// runtimeAliases are the executable names of the runtimes, without version
+var runtimeAliases = map[string]string{
+	"python":  "python",
+	"pythonw": "python",
+	"ruby":    "ruby",
+	"java":    "java",
+	"javaw":   "java",
+	"node":    "node",
+	"nodejs":  "node",
+	"php":     "php",
+	"php-fpm": "php",
+	"php-cgi": "php",
+	"perl":    "perl",
+}
+
+// runtimeDirPrefixes are how the directories of a runtime start, like
+// java-17-openjdk, jdk1.8.0_292 or node-v18.17.0-linux-x64
+var runtimeDirPrefixes = map[string][]string{
+	"python": {"python", "cpython"},
+	"ruby":   {"ruby"},
+	"java":   {"java", "jdk", "jre", "openjdk", "temurin", "zulu", "corretto", "graalvm", "adoptopenjdk"},
+	"node":   {"nodejs", "node"},
+	"php":    {"php"},
+	"perl":   {"perl"},
+}
+
+// versionManagers are the directories of the version managers
+var versionManagers = map[string]string{
+	".pyenv":  "pyenv",
+	"pyenv":   "pyenv",
+	".rbenv":  "rbenv",
+	"rbenv":   "rbenv",
+	".asdf":   "asdf",
+	".sdkman": "sdkman",
+	".nvm":    "nvm",
+}
+
+// runtimeVersionParents are the directories whose subdirectories are named
+// after the version alone, like ~/.pyenv/versions/3.11.4
+var runtimeVersionParents = map[string]bool{
+	"versions":   true,
+	"installs":   true,
+	"candidates": true,
+}
+
+// detectRuntime finds the runtime of c from the extractor that matched and
+// from the path of the executable, the resolved one if there is one
+func detectRuntime(isWindows bool, c *CommandLine) *Runtime {
+	exePath := c.ExecutePath
+	if c.Resolved != nil {
+		exePath = c.Resolved.Path
+	}
+	exe := strings.ToLower(executableName(isWindows, exePath))
+	base, exeVersion := splitVersion(exe)
+
+	name := ""
+	switch {
+	case c.Java != nil:
+		name = "java"
+	case c.Python != nil:
+		name = "python"
+	case c.Ruby != nil:
+		name = "ruby"
+	default:
+		name = runtimeAliases[base]
+	}
+	if name == "" {
+		return nil
+	}
+	if runtimeAliases[base] != name {
+		exeVersion = ""
+	}
+	exeVersion = versionCore(exeVersion)
+
+	r := &Runtime{Name: name, Version: exeVersion}
+	// the directories are looked up lower-cased
+	var buf [16]string
+	dirs := pathComponents(buf[:0], isWindows, strings.ToLower(exePath))
+	for _, dir := range dirs {
+		if manager, ok := versionManagers[dir]; ok {
+			r.Manager = manager
+		}
+	}
+	// the nearest directory with a version, the executable name may only
+	// have the major version like python3
+	for idx := len(dirs) - 1; idx >= 0; idx-- {
+		parent := ""
+		if idx > 0 {
+			parent = dirs[idx-1]
+		}
+		if v := dirVersion(name, dirs[idx], parent); v != "" {
+			if exeVersion == "" || hasVersionPrefix(v, exeVersion) {
+				r.Version = v
+			}
+			break
+		}
+	}
+	return r
+}
+
+// pathComponents appends the directories of the executable path to dirs
+func pathComponents(dirs []string, isWindows bool, exe string) []string {
+	start := 0
+	for idx := 0; idx < len(exe); idx++ {
+		if exe[idx] == '/' || isWindows && exe[idx] == '\\' {
+			if idx > start {
+				dirs = append(dirs, exe[start:idx])
+			}
+			start = idx + 1
+		}
+	}
+	return dirs
+}
+
+// dirVersion is the version of the runtime name in a directory of its
+// executable, like 2.7.11 in /opt/python/2.7.11/bin, v18.17.0 of nvm or
+// java-17-openjdk
+func dirVersion(name, dir, parent string) string {
+	if v := strings.TrimPrefix(dir, "v"); v != "" && v[0] >= '0' && v[0] <= '9' {
+		if parent == name || runtimeVersionParents[parent] || contains(runtimeDirPrefixes[name], parent) {
+			return versionCore(v)
+		}
+		return ""
+	}
+
+	for _, prefix := range runtimeDirPrefixes[name] {
+		if !strings.HasPrefix(dir, prefix) {
+			continue
+		}
+		v := strings.TrimLeft(dir[len(prefix):], "-_v")
+		if v == "" || v[0] < '0' || v[0] > '9' {
+			continue
+		}
+		v = versionCore(v)
+		// the windows installers of python drop the dot, like Python311
+		if name == "python" && !strings.Contains(v, ".") && len(v) > 1 {
+			v = v[:1] + "." + v[1:]
+		}
+		return v
+	}
+	return ""
+}
+
+// versionCore is the leading dotted numbers of v, "17.0.8" of "17.0.8+7"
+func versionCore(v string) string {
+	end := 0
+	for end < len(v) && (v[end] >= '0' && v[end] <= '9' || v[end] == '.' && end > 0 && end+1 < len(v) && v[end+1] >= '0' && v[end+1] <= '9') {
+		end++
+	}
+	return v[:end]
+}
+
+// hasVersionPrefix tells if the version v starts with the numbers of prefix,
+// "3.11.4" starts with "3" and "3.11" but not with "3.1"
+func hasVersionPrefix(v, prefix string) bool {
+	return v == prefix || strings.HasPrefix(v, prefix+".")
+}
+
+// CompareVersions compares the dotted versions a and b number by number, a
+// missing number is 0 and a leading "v" is ignored. The result is -1 when a
+// is older, 0 when they are equal and +1 when a is newer.
+func CompareVersions(a, b string) int {
+	as := strings.Split(versionCore(strings.TrimPrefix(a, "v")), ".")
+	bs := strings.Split(versionCore(strings.TrimPrefix(b, "v")), ".")
+	for idx := 0; idx < len(as) || idx < len(bs); idx++ {
+		var x, y int
+		if idx < len(as) {
+			x, _ = strconv.Atoi(as[idx])
+		}
+		if idx < len(bs) {
+			y, _ = strconv.Atoi(bs[idx])
+		}
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+	}
+	return 0
+}
+
+// AtLeast tells if the version of the runtime is known and not older than
+// version
+func (r *Runtime) AtLeast(version string) bool {
+	return r != nil && r.Version != "" && CompareVersions(r.Version, version) >= 0
+}
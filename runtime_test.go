@@ -0,0 +1,78 @@
+package cmdline
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRuntime(t *testing.T) {
+	tests := []struct {
+		isWindows bool
+		cmdline   string
+		expected  *Runtime
+	}{
+		{cmdline: "python3.11 app.py", expected: &Runtime{Name: "python", Version: "3.11"}},
+		{cmdline: "/opt/python/2.7.11/bin/python app.py", expected: &Runtime{Name: "python", Version: "2.7.11"}},
+		{cmdline: "/opt/python/2.7.11/bin/python3 app.py", expected: &Runtime{Name: "python", Version: "3"}},
+		{cmdline: "/home/dog/.pyenv/versions/3.11.4/bin/python3 app.py", expected: &Runtime{Name: "python", Version: "3.11.4", Manager: "pyenv"}},
+		{cmdline: "/home/dog/.pyenv/shims/python app.py", expected: &Runtime{Name: "python", Manager: "pyenv"}},
+		{cmdline: "/home/dog/.rbenv/versions/3.2.2/bin/ruby app.rb", expected: &Runtime{Name: "ruby", Version: "3.2.2", Manager: "rbenv"}},
+		{cmdline: "/home/dog/.asdf/installs/nodejs/18.17.0/bin/node server.js", expected: &Runtime{Name: "node", Version: "18.17.0", Manager: "asdf"}},
+		{cmdline: "/home/dog/.asdf/installs/java/temurin-17.0.8+7/bin/java Main", expected: &Runtime{Name: "java", Version: "17.0.8", Manager: "asdf"}},
+		{cmdline: "/home/dog/.sdkman/candidates/java/21.0.1-tem/bin/java Main", expected: &Runtime{Name: "java", Version: "21.0.1", Manager: "sdkman"}},
+		{cmdline: "/home/dog/.nvm/versions/node/v18.17.0/bin/node server.js", expected: &Runtime{Name: "node", Version: "18.17.0", Manager: "nvm"}},
+		{cmdline: "/usr/lib/jvm/java-17-openjdk/bin/java Main", expected: &Runtime{Name: "java", Version: "17"}},
+		{cmdline: "/usr/lib/jvm/jdk1.8.0_292/jre/bin/java Main", expected: &Runtime{Name: "java", Version: "1.8.0"}},
+		{cmdline: "/opt/node-v20.5.1-linux-x64/bin/node server.js", expected: &Runtime{Name: "node", Version: "20.5.1"}},
+		{cmdline: "php-fpm8.2 --nodaemonize", expected: &Runtime{Name: "php", Version: "8.2"}},
+		{cmdline: "/srv/app/1.0/bin/node server.js", expected: &Runtime{Name: "node"}},
+		{isWindows: true, cmdline: `C:\Python311\python.exe app.py`, expected: &Runtime{Name: "python", Version: "3.11"}},
+		{isWindows: true, cmdline: `"C:\Program Files\Java\jdk-17\bin\javaw.exe" -jar app.jar`, expected: &Runtime{Name: "java", Version: "17"}},
+		{cmdline: "nginx -g 'daemon off;'"},
+		{cmdline: "sudo -u dog python3 app.py"},
+	}
+
+	for _, tt := range tests {
+		c, _ := ParseCommandLine(tt.isWindows, tt.cmdline)
+		if assert.NotNil(t, c, tt.cmdline) {
+			assert.Equal(t, tt.expected, c.Runtime, tt.cmdline)
+		}
+	}
+
+	// the wrapped command has its own runtime
+	c, err := NewParser(WithMaxDepth(1)).ParseCommandLine("sudo -u dog /usr/bin/python3.11 app.py")
+	assert.NoError(t, err)
+	assert.Nil(t, c.Runtime)
+	assert.Equal(t, &Runtime{Name: "python", Version: "3.11"}, c.Sub.CommandLine.Runtime)
+
+	c, _ = ParseCommandLine(false, "/usr/lib/jvm/java-17-openjdk/bin/java Main")
+	assert.True(t, MustCompileMatcher(`runtime.version =~ "^17"`).Match(c))
+	assert.False(t, MustCompileMatcher(`runtime.manager != ""`).Match(c))
+}
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected int
+	}{
+		{"3.11", "3.11", 0},
+		{"3.11", "3.9", 1},
+		{"3.9", "3.11", -1},
+		{"3", "3.0.0", 0},
+		{"3", "3.1", -1},
+		{"v18.17.0", "18.2", 1},
+		{"17.0.8+7", "17.0.8", 0},
+		{"", "1", -1},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, CompareVersions(tt.a, tt.b), tt.a+" "+tt.b)
+	}
+
+	r := &Runtime{Name: "python", Version: "3.11.4"}
+	assert.True(t, r.AtLeast("3.8"))
+	assert.True(t, r.AtLeast("3.11.4"))
+	assert.False(t, r.AtLeast("3.12"))
+	assert.False(t, (&Runtime{Name: "python"}).AtLeast("2"))
+	assert.False(t, (*Runtime)(nil).AtLeast("2"))
+}
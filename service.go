@@ -1,17 +1,10 @@
 package cmdline
 
 import (
-	"errors"
-	"fmt"
 	"path/filepath"
 	"strings"
-	"unicode"
-
-	"github.com/mattn/go-shellwords"
 )
 
-type serviceExtractorFn func(cmdline *CommandLine) error
-
 const (
 	javaJarFlag      = "-jar"
 	javaJarExtension = ".jar"
@@ -19,7 +12,7 @@ const (
 )
 
 // List of binaries that usually have additional process context of whats running
-var binsWithContext = map[string]serviceExtractorFn{
+var binsWithContext = map[string]ExtractorFunc{
 	"python":    parseCommandContextPython,
 	"python2.7": parseCommandContextPython,
 	"python3":   parseCommandContextPython,
@@ -28,22 +21,51 @@ var binsWithContext = map[string]serviceExtractorFn{
 	"ruby":      parseCommandContextRuby,
 	"java":      parseCommandContextJava,
 	"java.exe":  parseCommandContextJava,
-	"sudo":      parseCommandContext,
+	"sudo":      parseCommandContextSudo,
 }
 
 type CommandLine struct {
+	// Env holds the "NAME=value" assignments in front of the executable
+	Env         []string
 	ExecutePath string
 	Args        []string
-
-	Sub    *SubCommand
-	Ruby   *RubyArgs
-	Python *PythonArgs
-	Java   *JavaArgs
+	// Expansions are the arguments with expanded environment references
+	Expansions []Expansion
+	// WorkingDir is the directory the process runs in, if known
+	WorkingDir string
+	// Runtime is the interpreter or virtual machine of the executable, nil
+	// for the other executables
+	Runtime *Runtime
+	// Resolved is the executable with its symbolic links followed, nil when
+	// it isn't resolved, see WithFS
+	Resolved *Resolution
+	// ViaShebang is set when the executable is a script run by the
+	// interpreter of its "#!" line, see WithFS. ExecutePath and Args are the
+	// ones of the interpreter then, Args[ScriptIndex] is the script.
+	ViaShebang  bool
+	ScriptIndex int
+
+	Sub     *SubCommand
+	Ruby    *RubyArgs
+	Python  *PythonArgs
+	Java    *JavaArgs
+	Windows *WindowsService
+	// Custom is set when a rule of a rule file matched, see Rule
+	Custom *CustomArgs
+
+	// Diagnostics are the problems that left the result partial
+	Diagnostics []Diagnostic
+
+	trace *tracer
 }
 
 type SubCommand struct {
 	Command string
 	Args    []string
+
+	// CommandLine is the parsed command, set when the parser is allowed to
+	// parse wrapped commands, see WithMaxDepth
+	CommandLine *CommandLine
 }
 
 type RubyArgs struct {
@@ -66,54 +88,38 @@ type JavaArgs struct {
 	Args []string
 }
 
+// ParseCommandLine parses the command line s, it is short for
+// NewParser(WithFlavor(...)).ParseCommandLine(s)
 func ParseCommandLine(isWindows bool, s string) (*CommandLine, error) {
-	if len(s) == 0 {
-		return &CommandLine{}, nil
-	}
-
-	p := shellwords.NewParser()
-	p.IsWindows = isWindows
-	_, args, err := p.ParseWithEnvs(s)
-	if err != nil {
-		return nil, err
-	}
-	if len(args) == 0 {
-		return nil, errors.New("invalid command - `" + s + "`")
-	}
-
-	exe := args[0]
-	// trim any quotes from the executable
-	exe = strings.Trim(exe, "\"")
-	return Parse(isWindows, exe, args[1:])
+	return defaultParser(isWindows).ParseCommandLine(s)
 }
 
+// Parse parses the executable exe with the arguments args, it is short for
+// NewParser(WithFlavor(...)).Parse(exe, args)
 func Parse(isWindows bool, exe string, args []string) (*CommandLine, error) {
-	c := &CommandLine{
-		ExecutePath: exe,
-		Args:        args,
-	}
+	return defaultParser(isWindows).Parse(exe, args)
+}
 
-	exe = removeFilePath(exe)
+// executableName returns the name of the executable without path and ".exe"
+func executableName(isWindows bool, exe string) string {
+	if isWindows {
+		exe = removeWindowsFilePath(exe)
+	} else {
+		exe = removeFilePath(exe)
+	}
 
 	if ext := filepath.Ext(exe); strings.ToLower(ext) == ".exe" {
 		exe = strings.TrimSuffix(exe, ext)
 	}
+	return exe
+}
 
-	if contextFn, ok := binsWithContext[exe]; ok {
-		return c, contextFn(c)
-	}
-
-	baseExe, _ := splitVersion(exe)
-	if contextFn, ok := binsWithContext[baseExe]; ok {
-		return c, contextFn(c)
+// diagnose records the error of an extractor, the result is partial
+func (c *CommandLine) diagnose(err error) error {
+	if err != nil {
+		c.Diagnostics = append(c.Diagnostics, Diagnostic{Index: -1, Err: err})
 	}
-
-	// // trim trailing file extensions
-	// if i := strings.LastIndex(exe, "."); i > 0 {
-	// 	exe = exe[:i]
-	// }
-
-	return c, nil
+	return err
 }
 
 func removeFilePath(s string) string {
@@ -124,141 +130,235 @@ func removeFilePath(s string) string {
 }
 
 func splitVersion(s string) (string, string) {
-	runes := []rune(s)
-	for index := len(runes) - 1; index >= 0; index-- {
-		if !unicode.IsDigit(runes[index]) && runes[index] != '.' {
+	for index := len(s) - 1; index >= 0; index-- {
+		if c := s[index]; (c < '0' || c > '9') && c != '.' {
 			return s[:index+1], s[index+1:]
 		}
 	}
 	return "", s
 }
 
-// In most cases, the best context is the first non-argument / environment variable, if it exists
-func parseCommandContext(cmdline *CommandLine) error {
-	var prevArgIsFlag bool
+// the options of sudo, the wrappers of the rule files have none
+var (
+	sudoGrammar = OptionGrammar{
+		Style: StyleGNU,
+		Options: []OptionSpec{
+			{Names: []string{"-u", "--user"}, Value: RequiredValue},
+			{Names: []string{"-g", "--group"}, Value: RequiredValue},
+			{Names: []string{"-U", "--other-user"}, Value: RequiredValue},
+			{Names: []string{"-C", "--close-from"}, Value: RequiredValue},
+			{Names: []string{"-D", "--chdir"}, Value: RequiredValue},
+			{Names: []string{"-R", "--chroot"}, Value: RequiredValue},
+			{Names: []string{"-h", "--host"}, Value: RequiredValue},
+			{Names: []string{"-p", "--prompt"}, Value: RequiredValue},
+			{Names: []string{"-r", "--role"}, Value: RequiredValue},
+			{Names: []string{"-t", "--type"}, Value: RequiredValue},
+			{Names: []string{"-T", "--command-timeout"}, Value: RequiredValue},
+			{Names: []string{"-E", "--preserve-env"}, Value: AttachedValue},
+			{Names: []string{"-A", "--askpass"}},
+			{Names: []string{"-b", "--background"}},
+			{Names: []string{"-B", "--bell"}},
+			{Names: []string{"-H", "--set-home"}},
+			{Names: []string{"-i", "--login"}},
+			{Names: []string{"-k", "--reset-timestamp"}},
+			{Names: []string{"-K", "--remove-timestamp"}},
+			{Names: []string{"-n", "--non-interactive"}},
+			{Names: []string{"-P", "--preserve-groups"}},
+			{Names: []string{"-S", "--stdin"}},
+			{Names: []string{"-s", "--shell"}},
+		},
+	}
+	wrapperGrammar = OptionGrammar{Style: StyleGNU}
+
+	pythonGrammar = OptionGrammar{
+		Style: StyleGNU,
+		Options: []OptionSpec{
+			{Names: []string{"-c"}, Value: RequiredValue, Terminal: true},
+			{Names: []string{"-m"}, Value: RequiredValue, Terminal: true},
+			{Names: []string{"-W"}, Value: RequiredValue},
+			{Names: []string{"-X"}, Value: RequiredValue},
+			{Names: []string{"-Q"}, Value: RequiredValue},
+			{Names: []string{"--check-hash-based-pycs"}, Value: RequiredValue},
+			{Names: []string{"-b"}}, {Names: []string{"-B"}}, {Names: []string{"-d"}},
+			{Names: []string{"-E"}}, {Names: []string{"-i"}}, {Names: []string{"-I"}},
+			{Names: []string{"-O"}}, {Names: []string{"-P"}}, {Names: []string{"-q"}},
+			{Names: []string{"-R"}}, {Names: []string{"-s"}}, {Names: []string{"-S"}},
+			{Names: []string{"-t"}}, {Names: []string{"-u"}}, {Names: []string{"-v"}},
+			{Names: []string{"-x"}}, {Names: []string{"-3"}},
+		},
+	}
+
+	rubyGrammar = OptionGrammar{
+		Style: StyleGNU,
+		Options: []OptionSpec{
+			{Names: []string{"-e"}, Value: RequiredValue},
+			{Names: []string{"-C"}, Value: RequiredValue},
+			{Names: []string{"-E", "--encoding"}, Value: RequiredValue},
+			{Names: []string{"-I"}, Value: RequiredValue},
+			{Names: []string{"-r"}, Value: RequiredValue},
+			{Names: []string{"--external-encoding"}, Value: RequiredValue},
+			{Names: []string{"--internal-encoding"}, Value: RequiredValue},
+			{Names: []string{"--enable"}, Value: RequiredValue},
+			{Names: []string{"--disable"}, Value: RequiredValue},
+			{Names: []string{"--dump"}, Value: RequiredValue},
+			{Names: []string{"-0"}, Value: AttachedValue},
+			{Names: []string{"-F"}, Value: AttachedValue},
+			{Names: []string{"-i"}, Value: AttachedValue},
+			{Names: []string{"-K"}, Value: AttachedValue},
+			{Names: []string{"-T"}, Value: AttachedValue},
+			{Names: []string{"-W"}, Value: AttachedValue},
+			{Names: []string{"-x"}, Value: AttachedValue},
+			{Names: []string{"-a"}}, {Names: []string{"-c"}}, {Names: []string{"-d", "--debug"}},
+			{Names: []string{"-l"}}, {Names: []string{"-n"}}, {Names: []string{"-p"}},
+			{Names: []string{"-s"}}, {Names: []string{"-S"}}, {Names: []string{"-v", "--verbose"}},
+			{Names: []string{"-w"}}, {Names: []string{"-y", "--yydebug"}},
+		},
+	}
 
-	for idx, a := range cmdline.Args {
-		hasFlagPrefix, isEnvVariable := strings.HasPrefix(a, "-"), strings.ContainsRune(a, '=')
-		shouldSkipArg := prevArgIsFlag || hasFlagPrefix || isEnvVariable
+	javaGrammar = OptionGrammar{
+		Style: StyleJava,
+		Options: []OptionSpec{
+			{Names: []string{javaJarFlag}, Value: RequiredValue, Terminal: true},
+			{Names: []string{"-m", "--module"}, Value: RequiredValue, Terminal: true},
+			{Names: []string{"-cp", "-classpath", "--class-path"}, Value: RequiredValue},
+			{Names: []string{"-p", "--module-path"}, Value: RequiredValue},
+			{Names: []string{"--upgrade-module-path"}, Value: RequiredValue},
+			{Names: []string{"--add-modules"}, Value: RequiredValue},
+			{Names: []string{"--add-reads"}, Value: RequiredValue},
+			{Names: []string{"--add-exports"}, Value: RequiredValue},
+			{Names: []string{"--add-opens"}, Value: RequiredValue},
+			{Names: []string{"--limit-modules"}, Value: RequiredValue},
+			{Names: []string{"--patch-module"}, Value: RequiredValue},
+			{Names: []string{"--enable-native-access"}, Value: RequiredValue},
+			{Names: []string{"--source"}, Value: RequiredValue},
+			{Names: []string{"-D"}, Prefix: true},
+			{Names: []string{"-X"}, Prefix: true},
+			{Names: []string{"-javaagent:"}, Prefix: true},
+			{Names: []string{"-agentlib:"}, Prefix: true},
+			{Names: []string{"-agentpath:"}, Prefix: true},
+			{Names: []string{"-verbose:"}, Prefix: true},
+			{Names: []string{"-splash:"}, Prefix: true},
+			{Names: []string{"-ea:", "-da:", "-enableassertions:", "-disableassertions:"}, Prefix: true},
+			{Names: []string{"-server"}}, {Names: []string{"-client"}},
+			{Names: []string{"-ea", "-enableassertions"}}, {Names: []string{"-da", "-disableassertions"}},
+			{Names: []string{"-esa", "-enablesystemassertions"}}, {Names: []string{"-dsa", "-disablesystemassertions"}},
+			{Names: []string{"-version", "--version"}}, {Names: []string{"-showversion", "--show-version"}},
+			{Names: []string{"-verbose"}}, {Names: []string{"--enable-preview"}},
+		},
+	}
+)
 
-		if !shouldSkipArg {
+func parseCommandContextSudo(cmdline *CommandLine) error {
+	return parseWrappedCommand(&sudoGrammar, cmdline)
+}
+
+// the command of a wrapper is the first argument after the options that
+// isn't an environment assignment
+func parseWrappedCommand(grammar *OptionGrammar, cmdline *CommandLine) error {
+	parsed := grammar.ParseOptions(cmdline.Args)
+	cmdline.traceOptions(parsed, TokenWrapperOption)
+	for idx := parsed.End; idx < len(cmdline.Args); idx++ {
+		if a := cmdline.Args[idx]; !strings.ContainsRune(a, '=') {
+			cmdline.traceArg(idx, TokenTarget, "")
 			cmdline.Sub = &SubCommand{
 				Command: a,
 				Args:    cmdline.Args[idx+1:],
 			}
 			return nil
 		}
-
-		prevArgIsFlag = hasFlagPrefix
+		cmdline.traceArg(idx, TokenEnv, "")
 	}
-	return errors.New("scriptfile not found")
+	return ErrNoCommand
 }
 
-// In most cases, the best context is the first non-argument / environment variable, if it exists
+// the script is the first argument after the options, ruby -e runs no script
 func parseCommandContextRuby(cmdline *CommandLine) error {
-	var prevArgIsFlag bool
-
-	for idx, a := range cmdline.Args {
-		hasFlagPrefix, isEnvVariable := strings.HasPrefix(a, "-"), strings.ContainsRune(a, '=')
-		shouldSkipArg := prevArgIsFlag || hasFlagPrefix || isEnvVariable
-
-		if !shouldSkipArg {
-			cmdline.Ruby = &RubyArgs{
-				FilePath: a,
-				Args:     cmdline.Args[idx+1:],
-			}
-			return nil
-		}
+	parsed := rubyGrammar.ParseOptions(cmdline.Args)
+	cmdline.traceOptions(parsed, TokenRuntimeOption)
+	if _, ok := parsed.Lookup("-e"); ok || parsed.End >= len(cmdline.Args) {
+		return ErrNoScript
+	}
 
-		prevArgIsFlag = hasFlagPrefix
+	cmdline.traceArg(parsed.End, TokenTarget, "")
+	cmdline.Ruby = &RubyArgs{
+		FilePath: cmdline.Args[parsed.End],
+		Args:     cmdline.Args[parsed.End+1:],
 	}
-	return errors.New("scriptfile not found")
+	return nil
 }
 
+// the script is the first argument after the options or the module of -m,
+// python -c runs no script
 func parseCommandContextPython(cmdline *CommandLine) error {
-	var (
-		prevArgIsFlag bool
-		moduleFlag    bool
-	)
-
-	for idx, a := range cmdline.Args {
-		hasFlagPrefix, isEnvVariable := strings.HasPrefix(a, "-"), strings.ContainsRune(a, '=')
-
-		shouldSkipArg := prevArgIsFlag || hasFlagPrefix || isEnvVariable
-
-		if !shouldSkipArg || moduleFlag {
-			cmdline.Python = &PythonArgs{
-				FilePath: a,
-				Args:     cmdline.Args[idx+1:],
+	parsed := pythonGrammar.ParseOptions(cmdline.Args)
+	cmdline.traceOptions(parsed, TokenRuntimeOption)
+	if terminal := parsed.Terminal(); terminal != nil {
+		if terminal.Spec.Names[0] != "-m" || !terminal.HasValue {
+			if terminal.HasValue {
+				cmdline.traceArg(terminal.ValueIndex, TokenOptionValue, terminal.Name)
 			}
-			return nil
+			return ErrNoScript
 		}
-
-		if hasFlagPrefix && a == "-m" {
-			moduleFlag = true
+		cmdline.traceArg(terminal.ValueIndex, TokenTarget, terminal.Name)
+		cmdline.Python = &PythonArgs{
+			FilePath: terminal.Value,
+			Args:     cmdline.Args[parsed.End:],
 		}
-
-		prevArgIsFlag = hasFlagPrefix
+		return nil
+	}
+	if parsed.End >= len(cmdline.Args) {
+		return ErrNoScript
 	}
 
-	return errors.New("scriptfile not found")
+	cmdline.traceArg(parsed.End, TokenTarget, "")
+	cmdline.Python = &PythonArgs{
+		FilePath: cmdline.Args[parsed.End],
+		Args:     cmdline.Args[parsed.End+1:],
+	}
+	return nil
 }
 
+// the main class is the first argument after the options, or the jar of
+// -jar or the module of -m
 func parseCommandContextJava(cmdline *CommandLine) error {
-	var jmxremoteEnable bool
-	var jmxremotePort string
-	var jmxremoteSsl bool
-	var jmxremoteAuthenticate bool
-
-	prevArgIsFlag := false
-
-	fmt.Println(cmdline.Args)
-
-	for idx, a := range cmdline.Args {
-		hasFlagPrefix := strings.HasPrefix(a, "-")
-		includesAssignment := strings.ContainsRune(a, '=') ||
-			strings.HasPrefix(a, "-X") ||
-			strings.HasPrefix(a, "-javaagent:") ||
-			strings.HasPrefix(a, "-verbose:")
-		shouldSkipArg := prevArgIsFlag || hasFlagPrefix || includesAssignment
-		if !shouldSkipArg {
-			cmdline.Java = &JavaArgs{
-				ClassName:       a,
-				Args:            cmdline.Args[idx+1:],
-				JmxEnable:       jmxremoteEnable,
-				JmxPort:         jmxremotePort,
-				JmxSsl:          jmxremoteSsl,
-				JmxAuthenticate: jmxremoteAuthenticate,
-			}
-			return nil
-		}
-
-		if strings.HasPrefix(a, "-Dcom.sun.management.jmxremote=") {
-			s := strings.TrimPrefix(a, "-Dcom.sun.management.jmxremote=")
-			jmxremoteEnable = strings.ToLower(s) == "true"
-		} else if a == "-Dcom.sun.management.jmxremote" {
-			jmxremoteEnable = true
-		}
-
-		if strings.HasPrefix(a, "-Dcom.sun.management.jmxremote.port=") {
-			jmxremotePort = strings.TrimPrefix(a, "-Dcom.sun.management.jmxremote.port=")
+	parsed := javaGrammar.ParseOptions(cmdline.Args)
+	cmdline.traceOptions(parsed, TokenRuntimeOption)
+
+	// java stays on the stack until a main class is found
+	var java JavaArgs
+	for _, o := range parsed.Options {
+		if o.Spec == nil || o.Spec.Names[0] != "-D" {
+			continue
 		}
-
-		if strings.HasPrefix(a, "-Dcom.sun.management.jmxremote.ssl") {
-			s := strings.TrimPrefix(a, "-Dcom.sun.management.jmxremote.ssl")
-			jmxremoteSsl = strings.ToLower(s) == "true"
-		} else if a == "-Dcom.sun.management.jmxremote.ssl" {
-			jmxremoteSsl = true
+		name, value, hasValue := strings.Cut(o.Value, "=")
+		enabled := !hasValue || strings.ToLower(value) == "true"
+		switch name {
+		case "com.sun.management.jmxremote":
+			java.JmxEnable = enabled
+		case "com.sun.management.jmxremote.port":
+			java.JmxPort = value
+		case "com.sun.management.jmxremote.ssl":
+			java.JmxSsl = enabled
+		case "com.sun.management.jmxremote.authenticate":
+			java.JmxAuthenticate = enabled
 		}
+	}
 
-		if strings.HasPrefix(a, "-Dcom.sun.management.jmxremote.authenticate") {
-			s := strings.TrimPrefix(a, "-Dcom.sun.management.jmxremote.authenticate")
-			jmxremoteAuthenticate = strings.ToLower(s) == "true"
-		} else if a == "-Dcom.sun.management.jmxremote.authenticate" {
-			jmxremoteAuthenticate = true
+	if terminal := parsed.Terminal(); terminal != nil {
+		if !terminal.HasValue {
+			return ErrNoMainClass
 		}
-
-		prevArgIsFlag = hasFlagPrefix && !includesAssignment && a != javaJarFlag
+		java.ClassName = terminal.Value
+		cmdline.traceArg(terminal.ValueIndex, TokenTarget, terminal.Name)
+	} else if parsed.End < len(cmdline.Args) {
+		java.ClassName = cmdline.Args[parsed.End]
+		cmdline.traceArg(parsed.End, TokenTarget, "")
+		parsed.End++
+	} else {
+		return ErrNoMainClass
 	}
-	return errors.New("classname not found")
+	java.Args = cmdline.Args[parsed.End:]
+	cmdline.Java = new(JavaArgs)
+	*cmdline.Java = java
+	return nil
 }
@@ -0,0 +1,245 @@
+package cmdline
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+// maxShebangLine is how much of a script the kernel reads for its "#!" line
+const maxShebangLine = 256
+
+// maxShebangs is how many interpreters in a row may be scripts, like the 4
+// of linux
+const maxShebangs = 4
+
+// envGrammar has the options of env that come before the command
+var envGrammar = OptionGrammar{
+	Style: StyleGNU,
+	Options: []OptionSpec{
+		{Names: []string{"-S", "--split-string"}, Value: RequiredValue},
+		{Names: []string{"-u", "--unset"}, Value: RequiredValue},
+		{Names: []string{"-C", "--chdir"}, Value: RequiredValue},
+		{Names: []string{"-P"}, Value: RequiredValue},
+		{Names: []string{"--default-signal", "--ignore-signal", "--block-signal"}, Value: AttachedValue},
+		{Names: []string{"-i", "--ignore-environment"}},
+		{Names: []string{"-0", "--null"}},
+		{Names: []string{"-v", "--debug"}},
+		{Names: []string{"--list-signal-handling"}},
+	},
+}
+
+// parseScript parses the command line of the interpreter of the script c
+// runs, nil when c runs no script with a "#!" line or it can't be read
+func (p *Parser) parseScript(depth, shebangs int, c *CommandLine) (*CommandLine, error) {
+	script := c.ExecutePath
+	if c.Resolved != nil {
+		script = c.Resolved.Path
+	} else if !path.IsAbs(script) {
+		if !strings.Contains(script, "/") || !path.IsAbs(p.workingDir) {
+			return nil, nil
+		}
+		script = path.Join(p.workingDir, script)
+	}
+
+	line, err := readShebang(p.fsys, strings.TrimPrefix(path.Clean(script), "/"))
+	if err != nil {
+		if p.logger != nil {
+			p.logger.Debug("script not read", "script", script, "depth", depth, "error", err)
+		}
+		return nil, nil
+	}
+	exe, arg := splitShebang(line)
+	if exe == "" {
+		return nil, nil
+	}
+	var env, args []string
+	if arg != "" {
+		args = []string{arg}
+	}
+	if executableName(false, exe) == "env" {
+		env, exe, args = p.unwrapEnv(exe, args)
+	}
+	if p.logger != nil {
+		p.logger.Debug("script interpreter", "script", script, "depth", depth, "interpreter", exe)
+	}
+
+	args = append(append(args, c.ExecutePath), c.Args...)
+	sc, err := p.parse(depth, shebangs+1, exe, args)
+	if len(env) > 0 {
+		sc.Env = append(env, sc.Env...)
+	}
+	sc.ViaShebang = true
+	sc.ScriptIndex = len(sc.Args) - len(c.Args) - 1
+	return sc, err
+}
+
+// readShebang returns the "#!" line of the file name without "#!", empty
+// when the file doesn't start with "#!"
+func readShebang(fsys fs.FS, name string) (string, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	buf := make([]byte, maxShebangLine)
+	n, err := io.ReadFull(f, buf)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+	line, ok := bytes.CutPrefix(buf[:n], []byte("#!"))
+	if !ok {
+		return "", nil
+	}
+	line, _, _ = bytes.Cut(line, []byte("\n"))
+	return string(line), nil
+}
+
+// splitShebang splits the "#!" line the way the kernel does, into the
+// interpreter and the rest as a single argument. A trailing '\r' of the
+// scripts written on windows is dropped too.
+func splitShebang(line string) (string, string) {
+	line = strings.Trim(line, " \t\r")
+	i := strings.IndexAny(line, " \t")
+	if i < 0 {
+		return line, ""
+	}
+	return line[:i], strings.TrimLeft(line[i:], " \t")
+}
+
+// unwrapEnv finds the command that env runs with args, like python3 -u of
+// "#!/usr/bin/env -S python3 -u", and the assignments in front of it. The
+// arguments of -S are split like env does. env with no command is kept.
+func (p *Parser) unwrapEnv(exe string, args []string) ([]string, string, []string) {
+	// every split is shorter than the -S it replaces
+	words := args
+	parsed := envGrammar.ParseOptions(words)
+	for {
+		split := -1
+		for idx, o := range parsed.Options {
+			if o.Spec != nil && o.Spec.Names[0] == "-S" && o.HasValue {
+				split = idx
+				break
+			}
+		}
+		if split < 0 {
+			break
+		}
+		o := parsed.Options[split]
+		spliced := append([]string{}, words[:o.Index]...)
+		spliced = append(spliced, splitEnvString(o.Value, p.env)...)
+		words = append(spliced, words[o.ValueIndex+1:]...)
+		parsed = envGrammar.ParseOptions(words)
+	}
+
+	for idx := parsed.End; idx < len(words); idx++ {
+		if strings.ContainsRune(words[idx], '=') {
+			continue
+		}
+		// copied, the caller appends to them
+		var env, rest []string
+		env = append(env, words[parsed.End:idx]...)
+		rest = append(rest, words[idx+1:]...)
+		return env, words[idx], rest
+	}
+	return nil, exe, args
+}
+
+// splitEnvString splits s like env -S does: at the blanks outside quotes,
+// with the escapes \_ for a blank, \c for the end and \t, \n and the like,
+// '#' starting a comment and ${NAME} expanded from env. The references to
+// variables missing from env are kept as they are.
+func splitEnvString(s string, env map[string]string) []string {
+	var (
+		words  []string
+		word   strings.Builder
+		inWord bool
+		quote  byte
+	)
+	flush := func() {
+		if inWord {
+			words = append(words, word.String())
+			word.Reset()
+			inWord = false
+		}
+	}
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote == '\'':
+			if c == '\'' {
+				quote = 0
+				continue
+			}
+			// only \\ and \' are escapes in single quotes
+			if c == '\\' && i+1 < len(s) && (s[i+1] == '\\' || s[i+1] == '\'') {
+				i++
+				c = s[i]
+			}
+			word.WriteByte(c)
+		case c == '\\' && i+1 < len(s):
+			i++
+			switch e := s[i]; e {
+			case 'c':
+				flush()
+				return words
+			case '_':
+				if quote == 0 {
+					flush()
+					continue
+				}
+				word.WriteByte(' ')
+			case 'f':
+				word.WriteByte('\f')
+			case 'n':
+				word.WriteByte('\n')
+			case 'r':
+				word.WriteByte('\r')
+			case 't':
+				word.WriteByte('\t')
+			case 'v':
+				word.WriteByte('\v')
+			default:
+				word.WriteByte(e)
+			}
+			inWord = true
+		case c == '$' && strings.HasPrefix(s[i:], "${"):
+			end := strings.IndexByte(s[i:], '}')
+			value, ok := "", false
+			if end > 0 {
+				value, ok = env[s[i+2:i+end]]
+			}
+			if !ok {
+				word.WriteByte(c)
+				inWord = true
+				continue
+			}
+			word.WriteString(value)
+			inWord = true
+			i += end
+		case quote == '"':
+			if c == '"' {
+				quote = 0
+				continue
+			}
+			word.WriteByte(c)
+		case c == '\'' || c == '"':
+			quote = c
+			inWord = true
+		case strings.IndexByte(" \t\n\v\f\r", c) >= 0:
+			flush()
+		case c == '#' && !inWord:
+			return words
+		default:
+			word.WriteByte(c)
+			inWord = true
+		}
+	}
+	flush()
+	return words
+}
@@ -0,0 +1,207 @@
+package cmdline
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func script(shebang string) *fstest.MapFile {
+	return &fstest.MapFile{Data: []byte(shebang + "\necho hello\n"), Mode: 0o755}
+}
+
+func TestShebang(t *testing.T) {
+	fsys := linkFS{fstest.MapFS{
+		"usr/local/bin/celery":  script("#!/usr/bin/python3"),
+		"srv/app/my-server.sh":  script("#!/bin/sh -e"),
+		"opt/app/server.py":     script("#!/usr/bin/env -S PYTHONUNBUFFERED=1 python3 -u\r"),
+		"opt/app/tool.py":       script("#! /usr/bin/env python3"),
+		"opt/bin/wrapper":       script("#!/opt/bin/interp"),
+		"opt/bin/interp":        script("#!/usr/bin/ruby -w"),
+		"opt/bin/self":          script("#!/opt/bin/self"),
+		"usr/sbin/nginx":        &fstest.MapFile{Data: []byte("\x7fELF\x02\x01\x01")},
+		"usr/bin/flower":        link("/opt/flower/bin/flower"),
+		"opt/flower/bin/flower": script("#!/opt/flower/bin/python3.11"),
+	}}
+	p := NewParser(WithFS(fsys), WithWorkingDir("/srv/app"))
+
+	tests := []struct {
+		cmdline  string
+		expected *CommandLine
+	}{
+		{
+			cmdline: "/usr/local/bin/celery -A proj worker",
+			expected: &CommandLine{
+				ExecutePath: "/usr/bin/python3",
+				Args:        []string{"/usr/local/bin/celery", "-A", "proj", "worker"},
+				ViaShebang:  true,
+				ScriptIndex: 0,
+			},
+		},
+		{
+			cmdline: "./my-server.sh --port 80",
+			expected: &CommandLine{
+				ExecutePath: "/bin/sh",
+				Args:        []string{"-e", "./my-server.sh", "--port", "80"},
+				ViaShebang:  true,
+				ScriptIndex: 1,
+			},
+		},
+		{
+			cmdline: "/opt/app/server.py --debug",
+			expected: &CommandLine{
+				Env:         []string{"PYTHONUNBUFFERED=1"},
+				ExecutePath: "python3",
+				Args:        []string{"-u", "/opt/app/server.py", "--debug"},
+				ViaShebang:  true,
+				ScriptIndex: 1,
+			},
+		},
+		{
+			cmdline: "/opt/app/tool.py",
+			expected: &CommandLine{
+				ExecutePath: "python3",
+				Args:        []string{"/opt/app/tool.py"},
+				ViaShebang:  true,
+			},
+		},
+		{
+			// the interpreter is a script too
+			cmdline: "/opt/bin/wrapper a",
+			expected: &CommandLine{
+				ExecutePath: "/usr/bin/ruby",
+				Args:        []string{"-w", "/opt/bin/interp", "/opt/bin/wrapper", "a"},
+				ViaShebang:  true,
+				ScriptIndex: 2,
+			},
+		},
+		{
+			cmdline:  "/usr/sbin/nginx -g daemon",
+			expected: &CommandLine{ExecutePath: "/usr/sbin/nginx", Args: []string{"-g", "daemon"}},
+		},
+		{
+			// no lookup in PATH
+			cmdline:  "celery worker",
+			expected: &CommandLine{ExecutePath: "celery", Args: []string{"worker"}},
+		},
+	}
+	for _, tt := range tests {
+		c, _ := p.ParseCommandLine(tt.cmdline)
+		if assert.NotNil(t, c, tt.cmdline) {
+			assert.Equal(t, tt.expected.Env, c.Env, tt.cmdline)
+			assert.Equal(t, tt.expected.ExecutePath, c.ExecutePath, tt.cmdline)
+			assert.Equal(t, tt.expected.Args, c.Args, tt.cmdline)
+			assert.Equal(t, tt.expected.ViaShebang, c.ViaShebang, tt.cmdline)
+			assert.Equal(t, tt.expected.ScriptIndex, c.ScriptIndex, tt.cmdline)
+		}
+	}
+
+	c, err := p.ParseCommandLine("/usr/local/bin/celery -A proj worker")
+	assert.NoError(t, err)
+	if assert.NotNil(t, c.Python) {
+		assert.Equal(t, "/usr/local/bin/celery", c.Python.FilePath)
+	}
+	assert.Equal(t, "celery", c.ServiceName())
+	assert.True(t, MustCompileMatcher(`shebang =~ "celery$" && runtime == "python"`).Match(c))
+
+	c, err = p.ParseCommandLine("/opt/bin/wrapper a")
+	assert.NoError(t, err)
+	if assert.NotNil(t, c.Ruby) {
+		assert.Equal(t, "/opt/bin/interp", c.Ruby.FilePath)
+	}
+
+	// the script is read through its links, it stays as invoked in Args
+	c, err = p.ParseCommandLine("/usr/bin/flower --port=5555")
+	assert.NoError(t, err)
+	assert.Equal(t, "/opt/flower/bin/python3.11", c.ExecutePath)
+	assert.Equal(t, []string{"/usr/bin/flower", "--port=5555"}, c.Args)
+	assert.Equal(t, &Runtime{Name: "python", Version: "3.11"}, c.Runtime)
+
+	// a script that is its own interpreter stops like the kernel does
+	c, _ = p.ParseCommandLine("/opt/bin/self")
+	assert.True(t, c.ViaShebang)
+	assert.Len(t, c.Args, maxShebangs)
+
+	// the wrapped commands may be scripts too
+	c, err = NewParser(WithFS(fsys), WithMaxDepth(1)).ParseCommandLine("sudo /usr/local/bin/celery worker")
+	assert.NoError(t, err)
+	assert.False(t, c.ViaShebang)
+	assert.True(t, c.Sub.CommandLine.ViaShebang)
+
+	// the expanded executable is the script now
+	c, err = NewParser(WithFS(fsys), WithEnv(map[string]string{"APP": "/opt/app/server.py"})).ParseCommandLine("$APP --debug")
+	assert.NoError(t, err)
+	if assert.Len(t, c.Expansions, 1) {
+		assert.Equal(t, Expansion{Index: 1, Original: "$APP"}, c.Expansions[0])
+	}
+
+	// nothing is read without WithFS
+	c, _ = ParseCommandLine(false, "/usr/local/bin/celery -A proj worker")
+	assert.False(t, c.ViaShebang)
+	assert.Equal(t, "/usr/local/bin/celery", c.ExecutePath)
+}
+
+func TestSplitShebang(t *testing.T) {
+	tests := []struct {
+		line, exe, arg string
+	}{
+		{"/bin/sh", "/bin/sh", ""},
+		{" /bin/sh -e ", "/bin/sh", "-e"},
+		{"/usr/bin/python3 -u -O\r", "/usr/bin/python3", "-u -O"},
+		{"\t/usr/bin/env\tpython3", "/usr/bin/env", "python3"},
+		{"", "", ""},
+	}
+	for _, tt := range tests {
+		exe, arg := splitShebang(tt.line)
+		assert.Equal(t, tt.exe, exe, tt.line)
+		assert.Equal(t, tt.arg, arg, tt.line)
+	}
+}
+
+func TestSplitEnvString(t *testing.T) {
+	env := map[string]string{"HOME": "/home/dog"}
+	tests := []struct {
+		s        string
+		expected []string
+	}{
+		{"python3 -u", []string{"python3", "-u"}},
+		{`  'a b' "c d"  `, []string{"a b", "c d"}},
+		{`a\_b "c\_d"`, []string{"a", "b", "c d"}},
+		{`'it\'s' "say \"hi\""`, []string{"it's", `say "hi"`}},
+		{`x\ty`, []string{"x\ty"}},
+		{"perl -w # the comment", []string{"perl", "-w"}},
+		{"a#b", []string{"a#b"}},
+		{`ruby \c -w`, []string{"ruby"}},
+		{`${HOME}/bin/app '${HOME}' ${MISSING}`, []string{"/home/dog/bin/app", "${HOME}", "${MISSING}"}},
+		{`"" x`, []string{"", "x"}},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, splitEnvString(tt.s, env), tt.s)
+	}
+}
+
+func TestUnwrapEnv(t *testing.T) {
+	tests := []struct {
+		args []string
+		env  []string
+		exe  string
+		rest []string
+	}{
+		{args: []string{"python3"}, exe: "python3"},
+		{args: []string{"-S python3 -u"}, exe: "python3", rest: []string{"-u"}},
+		{args: []string{"-S -i FOO=1 python3 -u"}, env: []string{"FOO=1"}, exe: "python3", rest: []string{"-u"}},
+		{args: []string{"-vS node --inspect"}, exe: "node", rest: []string{"--inspect"}},
+		{args: []string{"--split-string=ruby -w"}, exe: "ruby", rest: []string{"-w"}},
+		{args: []string{"-S -u LANG -C /tmp -S 'perl -T'"}, exe: "perl", rest: []string{"-T"}},
+		{args: []string{"-i"}, exe: "/usr/bin/env", rest: []string{"-i"}},
+	}
+	p := NewParser()
+	for _, tt := range tests {
+		env, exe, rest := p.unwrapEnv("/usr/bin/env", tt.args)
+		assert.Equal(t, tt.env, env, tt.args)
+		assert.Equal(t, tt.exe, exe, tt.args)
+		assert.Equal(t, tt.rest, rest, tt.args)
+	}
+}
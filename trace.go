@@ -0,0 +1,87 @@
+package cmdline
+
+import (
+	"log/slog"
+)
+
+// tracer tells the logger of the parser how an extractor reads the
+// arguments, and keeps the roles of the arguments for ParseExplained. It is
+// only set on the CommandLine while it is parsed.
+type tracer struct {
+	logger *slog.Logger
+	// tokens are the roles of Args when explaining, nil otherwise
+	tokens []tokenTrace
+	// strict reports the unknown options of the grammars in Diagnostics,
+	// for StrictnessStrict
+	strict bool
+}
+
+type tokenTrace struct {
+	set     bool
+	role    TokenRole
+	option  string
+	value   string
+	unknown bool
+}
+
+// traceArg records the role of the argument idx, option is the option of a
+// value
+func (c *CommandLine) traceArg(idx int, role TokenRole, option string) {
+	c.traceToken(idx, tokenTrace{set: true, role: role, option: option})
+}
+
+func (c *CommandLine) traceToken(idx int, t tokenTrace) {
+	if c.trace == nil || idx < 0 || idx >= len(c.Args) {
+		return
+	}
+	if c.trace.tokens != nil {
+		c.trace.tokens[idx] = t
+	}
+	if c.trace.logger == nil {
+		return
+	}
+
+	msg := "argument skipped"
+	if t.role == TokenTarget {
+		msg = "target found"
+	}
+	reason := t.role.String()
+	if t.unknown {
+		reason = "unknown " + reason
+	}
+	attrs := []any{"index", idx, "arg", c.Args[idx], "reason", reason}
+	if t.option != "" {
+		attrs = append(attrs, "option", t.option)
+	}
+	c.trace.logger.Debug(msg, attrs...)
+}
+
+// traceOptions records the options of parsed, role is TokenWrapperOption or
+// TokenRuntimeOption. The value of a Terminal option is left to the
+// extractor, it is usually the target. The unknown options are diagnostics
+// when strict.
+func (c *CommandLine) traceOptions(parsed *ParsedOptions, role TokenRole) {
+	if c.trace == nil {
+		return
+	}
+	for _, o := range parsed.Options {
+		if o.Spec == nil && c.trace.strict {
+			exe := executableName(c.isWindows(), c.ExecutePath)
+			c.Diagnostics = append(c.Diagnostics, Diagnostic{
+				Index: o.Index,
+				Err:   &UnknownOptionError{Executable: exe, Option: o.Name, Index: o.Index},
+			})
+		}
+		t := tokenTrace{set: true, role: role, unknown: o.Spec == nil}
+		if o.HasValue {
+			t.value = o.Value
+		}
+		c.traceToken(o.Index, t)
+		if o.ValueIndex != o.Index && (o.Spec == nil || !o.Spec.Terminal) {
+			c.traceArg(o.ValueIndex, TokenOptionValue, o.Name)
+		}
+	}
+	if end := parsed.End; end > 0 && end <= len(c.Args) && c.Args[end-1] == "--" {
+		c.traceArg(end-1, TokenEndOfOptions, "")
+	}
+}
@@ -0,0 +1,102 @@
+package cmdline
+
+import (
+	"strings"
+)
+
+// SplitWindows splits a windows command line into arguments the way
+// CommandLineToArgvW and the Microsoft C runtime do.
+//
+// The first token is the program name and follows its own rule: when it
+// starts with a double quote it runs to the next double quote, otherwise it
+// runs to the first space or tab, and backslashes in it are never special.
+//
+// For the other tokens:
+//   - 2n backslashes followed by a double quote produce n backslashes and the
+//     double quote toggles quoted mode
+//   - 2n+1 backslashes followed by a double quote produce n backslashes and a
+//     literal double quote
+//   - backslashes not followed by a double quote are literal
+//   - inside quoted mode two double quotes produce a literal double quote and
+//     stay in quoted mode (the behaviour of the runtimes since 2008)
+//
+// An unterminated quote runs to the end of the line, as it does on windows.
+func SplitWindows(s string) ([]string, error) {
+	if idx := strings.IndexByte(s, 0); idx >= 0 {
+		return nil, &TokenizeError{Input: s, Offset: idx, Reason: "NUL character"}
+	}
+
+	args := []string{}
+	if len(s) == 0 {
+		return args, nil
+	}
+
+	var rest string
+	if s[0] == '"' {
+		end := strings.IndexByte(s[1:], '"')
+		if end < 0 {
+			return append(args, s[1:]), nil
+		}
+		args = append(args, s[1:1+end])
+		rest = s[end+2:]
+	} else {
+		end := strings.IndexAny(s, " \t")
+		if end < 0 {
+			return append(args, s), nil
+		}
+		args = append(args, s[:end])
+		rest = s[end:]
+	}
+
+	for {
+		rest = strings.TrimLeft(rest, " \t")
+		if len(rest) == 0 {
+			return args, nil
+		}
+
+		var arg string
+		arg, rest = readWindowsArg(rest)
+		args = append(args, arg)
+	}
+}
+
+func readWindowsArg(s string) (string, string) {
+	var (
+		sb       strings.Builder
+		inQuote  bool
+		nslashes int
+	)
+
+	for idx := 0; idx < len(s); idx++ {
+		c := s[idx]
+		switch c {
+		case '\\':
+			nslashes++
+			continue
+		case '"':
+			sb.WriteString(strings.Repeat("\\", nslashes/2))
+			if nslashes%2 == 1 {
+				sb.WriteByte('"')
+			} else if inQuote && idx+1 < len(s) && s[idx+1] == '"' {
+				sb.WriteByte('"')
+				idx++
+			} else {
+				inQuote = !inQuote
+			}
+			nslashes = 0
+			continue
+		case ' ', '\t':
+			if !inQuote {
+				sb.WriteString(strings.Repeat("\\", nslashes))
+				return sb.String(), s[idx+1:]
+			}
+		}
+
+		sb.WriteString(strings.Repeat("\\", nslashes))
+		nslashes = 0
+		sb.WriteByte(c)
+	}
+
+	sb.WriteString(strings.Repeat("\\", nslashes))
+	return sb.String(), ""
+}
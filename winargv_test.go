@@ -0,0 +1,82 @@
+package cmdline
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitWindows(t *testing.T) {
+	tests := []struct {
+		name     string
+		cmdline  string
+		expected []string
+	}{
+		{name: "empty", cmdline: ``, expected: []string{}},
+		{name: "program only", cmdline: `prog`, expected: []string{`prog`}},
+		{name: "program with trailing space", cmdline: `prog  `, expected: []string{`prog`}},
+		{name: "leading space gives empty program", cmdline: ` prog a`, expected: []string{``, `prog`, `a`}},
+		{name: "tabs separate", cmdline: "prog\ta\t\tb", expected: []string{`prog`, `a`, `b`}},
+
+		// the program name never treats backslashes as escapes
+		{name: "quoted program with trailing backslashes", cmdline: `"C:\Program Files\App\\" -x`, expected: []string{`C:\Program Files\App\\`, `-x`}},
+		{name: "quoted program", cmdline: `"C:\Program Files\java.exe" -version`, expected: []string{`C:\Program Files\java.exe`, `-version`}},
+		{name: "unquoted program with backslash quote", cmdline: `C:\a\"b c`, expected: []string{`C:\a\"b`, `c`}},
+		{name: "quoted program followed by text", cmdline: `"a"b c`, expected: []string{`a`, `b`, `c`}},
+		{name: "unterminated quoted program", cmdline: `"C:\Program Files\app.exe -x`, expected: []string{`C:\Program Files\app.exe -x`}},
+		{name: "empty quoted program", cmdline: `"" a`, expected: []string{``, `a`}},
+
+		// examples from "Parsing C command-line arguments"
+		{name: "docs quoted arg", cmdline: `prog "a b c" d e`, expected: []string{`prog`, `a b c`, `d`, `e`}},
+		{name: "docs escaped quote", cmdline: `prog "ab\"c" "\\" d`, expected: []string{`prog`, `ab"c`, `\`, `d`}},
+		{name: "docs literal backslashes", cmdline: `prog a\\\b d"e f"g h`, expected: []string{`prog`, `a\\\b`, `de fg`, `h`}},
+		{name: "docs odd backslashes before quote", cmdline: `prog a\\\"b c d`, expected: []string{`prog`, `a\"b`, `c`, `d`}},
+		{name: "docs even backslashes before quote", cmdline: `prog a\\\\"b c" d e`, expected: []string{`prog`, `a\\b c`, `d`, `e`}},
+		{name: "docs double quote in quoted mode", cmdline: `prog a"b"" c d`, expected: []string{`prog`, `ab" c d`}},
+
+		{name: "backslashes without quote", cmdline: `prog a\\\\b`, expected: []string{`prog`, `a\\\\b`}},
+		{name: "trailing backslashes", cmdline: `prog a\\`, expected: []string{`prog`, `a\\`}},
+		{name: "trailing backslash before quote in quoted arg", cmdline: `prog "a\\"`, expected: []string{`prog`, `a\`}},
+		{name: "escaped quote alone", cmdline: `prog \"`, expected: []string{`prog`, `"`}},
+		{name: "escaped quote in quoted arg", cmdline: `prog "\"a\""`, expected: []string{`prog`, `"a"`}},
+		{name: "three backslashes and quote", cmdline: `prog \\\"`, expected: []string{`prog`, `\"`}},
+		{name: "empty arg", cmdline: `prog ""`, expected: []string{`prog`, ``}},
+		{name: "two empty args", cmdline: `prog "" ""`, expected: []string{`prog`, ``, ``}},
+		{name: "empty quotes join", cmdline: `prog a""b`, expected: []string{`prog`, `ab`}},
+		{name: "quote in middle", cmdline: `prog a"b c"d`, expected: []string{`prog`, `ab cd`}},
+		{name: "four quotes", cmdline: `prog """"`, expected: []string{`prog`, `"`}},
+		{name: "tripled quotes", cmdline: `prog """a"""`, expected: []string{`prog`, `"a"`}},
+		{name: "doubled quotes outside quoted mode", cmdline: `prog ""a""`, expected: []string{`prog`, `a`}},
+		{name: "unterminated quoted arg", cmdline: `prog "a b`, expected: []string{`prog`, `a b`}},
+		{name: "unterminated quoted arg with trailing space", cmdline: `prog "a b `, expected: []string{`prog`, `a b `}},
+		{name: "multiple spaces", cmdline: `prog   a    b`, expected: []string{`prog`, `a`, `b`}},
+		{name: "spaces inside quotes kept", cmdline: `prog "  a  "`, expected: []string{`prog`, `  a  `}},
+		{name: "quoted path with spaces", cmdline: `prog /d "C:\Program Files\App\"`, expected: []string{`prog`, `/d`, `C:\Program Files\App"`}},
+		{name: "quoted path with escaped trailing backslash", cmdline: `prog /d "C:\Program Files\App\\"`, expected: []string{`prog`, `/d`, `C:\Program Files\App\`}},
+		{name: "semicolons are not special", cmdline: `java -cp a.jar;b.jar Main`, expected: []string{`java`, `-cp`, `a.jar;b.jar`, `Main`}},
+		{name: "shell operators are not special", cmdline: `prog a|b a&b a>b`, expected: []string{`prog`, `a|b`, `a&b`, `a>b`}},
+		{name: "single quotes are not special", cmdline: `prog 'a b'`, expected: []string{`prog`, `'a`, `b'`}},
+		{name: "unicode", cmdline: `"C:\程序\app.exe" "数据 目录"`, expected: []string{`C:\程序\app.exe`, `数据 目录`}},
+		{name: "property with quoted value", cmdline: `java "-Dname=a b" Main`, expected: []string{`java`, `-Dname=a b`, `Main`}},
+		{name: "glued quoted value", cmdline: `java -Dname="a b" Main`, expected: []string{`java`, `-Dname=a b`, `Main`}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args, err := SplitWindows(tt.cmdline)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+
+			assert.Equal(t, tt.expected, args)
+		})
+	}
+}
+
+func TestSplitWindowsNul(t *testing.T) {
+	_, err := SplitWindows("prog a\x00b")
+	if err == nil {
+		t.Error("want error got ok")
+	}
+}
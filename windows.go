@@ -0,0 +1,240 @@
+package cmdline
+
+import (
+	"fmt"
+	"strings"
+)
+
+// List of windows binaries that host the real component, keyed by the lower-cased name without ".exe"
+var windowsBinsWithContext = map[string]ExtractorFunc{
+	"svchost":   parseCommandContextSvchost,
+	"rundll32":  parseCommandContextRundll32,
+	"dllhost":   parseCommandContextDllhost,
+	"msiexec":   parseCommandContextMsiexec,
+	"taskhostw": parseCommandContextTaskhostw,
+}
+
+type WindowsService struct {
+	// Host is the lower-cased name of the host binary, e.g. "svchost"
+	Host string
+
+	// svchost.exe -k <Group> -p -s <ServiceName>
+	Group       string
+	ServiceName string
+	Policy      bool
+
+	// rundll32.exe <DLL>,<EntryPoint> args
+	DLL        string
+	EntryPoint string
+
+	// dllhost.exe /Processid:{GUID}
+	ProcessID string
+
+	// msiexec.exe /i <Package>
+	Action  string
+	Package string
+
+	// taskhostw.exe <Task>
+	Task string
+
+	Args []string
+}
+
+func removeWindowsFilePath(s string) string {
+	if i := strings.LastIndexAny(s, "\\/"); i >= 0 {
+		return s[i+1:]
+	}
+	return s
+}
+
+// windows tools accept both "-k" and "/k", case insensitive
+func windowsOption(a string) (string, bool) {
+	if len(a) < 2 || (a[0] != '-' && a[0] != '/') {
+		return "", false
+	}
+	return strings.ToLower(a[1:]), true
+}
+
+func parseCommandContextSvchost(cmdline *CommandLine) error {
+	svc := &WindowsService{
+		Host: "svchost",
+		Args: []string{},
+	}
+
+	// the service is the target, or the group when it hosts no single one
+	groupIdx, serviceIdx := -1, -1
+	for idx := 0; idx < len(cmdline.Args); idx++ {
+		opt, ok := windowsOption(cmdline.Args[idx])
+		if !ok {
+			svc.Args = append(svc.Args, cmdline.Args[idx])
+			continue
+		}
+
+		switch opt {
+		case "k", "s":
+			cmdline.traceArg(idx, TokenWrapperOption, "")
+			if idx+1 >= len(cmdline.Args) {
+				svc.Args = append(svc.Args, cmdline.Args[idx])
+				continue
+			}
+			idx++
+			if opt == "k" {
+				svc.Group, groupIdx = cmdline.Args[idx], idx
+			} else {
+				svc.ServiceName, serviceIdx = cmdline.Args[idx], idx
+			}
+		case "p":
+			cmdline.traceArg(idx, TokenWrapperOption, "")
+			svc.Policy = true
+		default:
+			cmdline.traceToken(idx, tokenTrace{set: true, role: TokenWrapperOption, unknown: true})
+			cmdline.Diagnostics = append(cmdline.Diagnostics, Diagnostic{
+				Index: idx,
+				Err:   &UnknownOptionError{Executable: "svchost", Option: cmdline.Args[idx], Index: idx},
+			})
+			svc.Args = append(svc.Args, cmdline.Args[idx])
+		}
+	}
+	if groupIdx >= 0 {
+		role := TokenTarget
+		if serviceIdx >= 0 {
+			role = TokenOptionValue
+		}
+		cmdline.traceArg(groupIdx, role, cmdline.Args[groupIdx-1])
+	}
+	if serviceIdx >= 0 {
+		cmdline.traceArg(serviceIdx, TokenTarget, cmdline.Args[serviceIdx-1])
+	}
+
+	cmdline.Windows = svc
+	if svc.Group == "" {
+		return fmt.Errorf("service group: %w", ErrNoComponent)
+	}
+	return nil
+}
+
+func parseCommandContextRundll32(cmdline *CommandLine) error {
+	for idx, a := range cmdline.Args {
+		if strings.HasPrefix(a, "-") {
+			cmdline.traceArg(idx, TokenWrapperOption, "")
+			continue
+		}
+
+		cmdline.traceArg(idx, TokenTarget, "")
+		svc := &WindowsService{
+			Host: "rundll32",
+			Args: cmdline.Args[idx+1:],
+		}
+		if i := strings.IndexByte(a, ','); i >= 0 {
+			svc.DLL = strings.TrimSpace(a[:i])
+			svc.EntryPoint = strings.TrimSpace(a[i+1:])
+		} else {
+			svc.DLL = a
+			if len(svc.Args) > 0 {
+				cmdline.traceArg(idx+1, TokenTarget, "")
+				svc.EntryPoint = svc.Args[0]
+				svc.Args = svc.Args[1:]
+			}
+		}
+		svc.DLL = strings.Trim(svc.DLL, "\"")
+		cmdline.Windows = svc
+		return nil
+	}
+	return fmt.Errorf("dll: %w", ErrNoComponent)
+}
+
+func parseCommandContextDllhost(cmdline *CommandLine) error {
+	for idx, a := range cmdline.Args {
+		opt, ok := windowsOption(a)
+		if !ok || !strings.HasPrefix(opt, "processid:") {
+			continue
+		}
+
+		cmdline.traceArg(idx, TokenTarget, "")
+		args := make([]string, 0, len(cmdline.Args)-1)
+		args = append(args, cmdline.Args[:idx]...)
+		args = append(args, cmdline.Args[idx+1:]...)
+		cmdline.Windows = &WindowsService{
+			Host:      "dllhost",
+			ProcessID: a[len("/processid:"):],
+			Args:      args,
+		}
+		return nil
+	}
+	return fmt.Errorf("processid: %w", ErrNoComponent)
+}
+
+var msiexecActions = map[string]string{
+	"i":         "install",
+	"package":   "install",
+	"a":         "admin",
+	"j":         "advertise",
+	"f":         "repair",
+	"x":         "uninstall",
+	"uninstall": "uninstall",
+	"p":         "patch",
+	"update":    "patch",
+}
+
+func parseCommandContextMsiexec(cmdline *CommandLine) error {
+	svc := &WindowsService{
+		Host: "msiexec",
+		Args: []string{},
+	}
+
+	for idx := 0; idx < len(cmdline.Args); idx++ {
+		opt, ok := windowsOption(cmdline.Args[idx])
+		if !ok {
+			svc.Args = append(svc.Args, cmdline.Args[idx])
+			continue
+		}
+
+		if opt == "v" {
+			// msiexec.exe /V is the Windows Installer service itself
+			cmdline.traceArg(idx, TokenWrapperOption, "")
+			svc.Action = "service"
+			continue
+		}
+
+		// repair and advertise take their mode glued on the option, e.g. "/fa" or "/ju"
+		name := opt
+		if len(name) > 1 && name[0] == 'f' && strings.Trim(name[1:], "pomuseacv") == "" {
+			name = "f"
+		} else if len(name) > 1 && name[0] == 'j' && strings.Trim(name[1:], "um") == "" {
+			name = "j"
+		}
+		action, ok := msiexecActions[name]
+		if !ok || idx+1 >= len(cmdline.Args) {
+			cmdline.traceArg(idx, TokenWrapperOption, "")
+			svc.Args = append(svc.Args, cmdline.Args[idx])
+			continue
+		}
+
+		cmdline.traceArg(idx, TokenWrapperOption, "")
+		cmdline.traceArg(idx+1, TokenTarget, cmdline.Args[idx])
+		idx++
+		svc.Action = action
+		svc.Package = cmdline.Args[idx]
+	}
+
+	cmdline.Windows = svc
+	if svc.Action == "" {
+		return fmt.Errorf("package: %w", ErrNoComponent)
+	}
+	return nil
+}
+
+func parseCommandContextTaskhostw(cmdline *CommandLine) error {
+	svc := &WindowsService{
+		Host: "taskhostw",
+	}
+	if len(cmdline.Args) > 0 {
+		cmdline.traceArg(0, TokenTarget, "")
+		svc.Task = cmdline.Args[0]
+		svc.Args = cmdline.Args[1:]
+	} else {
+		svc.Args = cmdline.Args
+	}
+	cmdline.Windows = svc
+	return nil
+}